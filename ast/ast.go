@@ -1,6 +1,8 @@
 package ast
 
 import (
+	"math/big"
+	"strconv"
 	"strings"
 
 	"github.com/stevecallear/mexl/ast/token"
@@ -10,6 +12,8 @@ type (
 	Node interface {
 		TokenLiteral() string
 		String() string
+		Pos() token.Position
+		End() token.Position
 	}
 
 	Identifier struct {
@@ -27,6 +31,12 @@ type (
 		Value float64
 	}
 
+	// DecimalLiteral represents an exact decimal number, for example 1.10d
+	DecimalLiteral struct {
+		Token token.Token
+		Value *big.Rat
+	}
+
 	StringLiteral struct {
 		Token token.Token
 		Value string
@@ -44,12 +54,31 @@ type (
 	ArrayLiteral struct {
 		Token    token.Token
 		Elements []Node
+		Close    token.Token
+	}
+
+	MapLiteral struct {
+		Token  token.Token
+		Keys   []Node
+		Values []Node
+		Close  token.Token
 	}
 
 	IndexExpression struct {
 		Token token.Token
 		Left  Node
 		Index Node
+		Close token.Token
+	}
+
+	// SliceExpression represents a slice of an array or string
+	// Low and High are nil if the bound is omitted.
+	SliceExpression struct {
+		Token token.Token
+		Left  Node
+		Low   Node
+		High  Node
+		Close token.Token
 	}
 
 	MemberExpression struct {
@@ -71,10 +100,24 @@ type (
 		Right    Node
 	}
 
+	ConditionalExpression struct {
+		Token       token.Token
+		Condition   Node
+		Consequence Node
+		Alternative Node
+	}
+
+	Lambda struct {
+		Token     token.Token
+		Parameter *Identifier
+		Body      Node
+	}
+
 	CallExpression struct {
 		Token     token.Token
 		Function  Node
 		Arguments []Node
+		Close     token.Token
 	}
 )
 
@@ -86,6 +129,14 @@ func (i *Identifier) String() string {
 	return i.Value
 }
 
+func (i *Identifier) Pos() token.Position {
+	return i.Token.Start
+}
+
+func (i *Identifier) End() token.Position {
+	return i.Token.End
+}
+
 func (i *IntegerLiteral) TokenLiteral() string {
 	return i.Token.Literal
 }
@@ -94,6 +145,14 @@ func (i *IntegerLiteral) String() string {
 	return i.Token.Literal
 }
 
+func (i *IntegerLiteral) Pos() token.Position {
+	return i.Token.Start
+}
+
+func (i *IntegerLiteral) End() token.Position {
+	return i.Token.End
+}
+
 func (f *FloatLiteral) TokenLiteral() string {
 	return f.Token.Literal
 }
@@ -102,6 +161,30 @@ func (f *FloatLiteral) String() string {
 	return f.Token.Literal
 }
 
+func (f *FloatLiteral) Pos() token.Position {
+	return f.Token.Start
+}
+
+func (f *FloatLiteral) End() token.Position {
+	return f.Token.End
+}
+
+func (d *DecimalLiteral) TokenLiteral() string {
+	return d.Token.Literal
+}
+
+func (d *DecimalLiteral) String() string {
+	return d.Token.Literal
+}
+
+func (d *DecimalLiteral) Pos() token.Position {
+	return d.Token.Start
+}
+
+func (d *DecimalLiteral) End() token.Position {
+	return d.Token.End
+}
+
 func (i *StringLiteral) TokenLiteral() string {
 	return i.Token.Literal
 }
@@ -110,6 +193,14 @@ func (i *StringLiteral) String() string {
 	return i.Token.Literal
 }
 
+func (i *StringLiteral) Pos() token.Position {
+	return i.Token.Start
+}
+
+func (i *StringLiteral) End() token.Position {
+	return i.Token.End
+}
+
 func (b *Boolean) TokenLiteral() string {
 	return b.Token.Literal
 }
@@ -118,6 +209,14 @@ func (b *Boolean) String() string {
 	return b.Token.Literal
 }
 
+func (b *Boolean) Pos() token.Position {
+	return b.Token.Start
+}
+
+func (b *Boolean) End() token.Position {
+	return b.Token.End
+}
+
 func (b *Null) TokenLiteral() string {
 	return b.Token.Literal
 }
@@ -126,6 +225,14 @@ func (b *Null) String() string {
 	return b.Token.Literal
 }
 
+func (b *Null) Pos() token.Position {
+	return b.Token.Start
+}
+
+func (b *Null) End() token.Position {
+	return b.Token.End
+}
+
 func (a *ArrayLiteral) TokenLiteral() string {
 	return a.Token.Literal
 }
@@ -138,6 +245,38 @@ func (a *ArrayLiteral) String() string {
 	return "[" + strings.Join(es, ", ") + "]"
 }
 
+func (a *ArrayLiteral) Pos() token.Position {
+	return a.Token.Start
+}
+
+func (a *ArrayLiteral) End() token.Position {
+	return a.Close.End
+}
+
+func (m *MapLiteral) TokenLiteral() string {
+	return m.Token.Literal
+}
+
+func (m *MapLiteral) String() string {
+	ps := make([]string, len(m.Keys))
+	for i, k := range m.Keys {
+		ks := k.String()
+		if sl, ok := k.(*StringLiteral); ok {
+			ks = strconv.Quote(sl.Value)
+		}
+		ps[i] = ks + ": " + m.Values[i].String()
+	}
+	return "{" + strings.Join(ps, ", ") + "}"
+}
+
+func (m *MapLiteral) Pos() token.Position {
+	return m.Token.Start
+}
+
+func (m *MapLiteral) End() token.Position {
+	return m.Close.End
+}
+
 func (i *IndexExpression) TokenLiteral() string {
 	return i.Token.Literal
 }
@@ -146,6 +285,38 @@ func (i *IndexExpression) String() string {
 	return "(" + i.Left.String() + "[" + i.Index.String() + "])"
 }
 
+func (i *IndexExpression) Pos() token.Position {
+	return i.Left.Pos()
+}
+
+func (i *IndexExpression) End() token.Position {
+	return i.Close.End
+}
+
+func (s *SliceExpression) TokenLiteral() string {
+	return s.Token.Literal
+}
+
+func (s *SliceExpression) String() string {
+	var low, high string
+	if s.Low != nil {
+		low = s.Low.String()
+	}
+	if s.High != nil {
+		high = s.High.String()
+	}
+
+	return "(" + s.Left.String() + "[" + low + ":" + high + "])"
+}
+
+func (s *SliceExpression) Pos() token.Position {
+	return s.Left.Pos()
+}
+
+func (s *SliceExpression) End() token.Position {
+	return s.Close.End
+}
+
 func (m *MemberExpression) TokenLiteral() string {
 	return m.Token.Literal
 }
@@ -154,6 +325,14 @@ func (m *MemberExpression) String() string {
 	return "(" + m.Left.String() + "." + m.Member.String() + ")"
 }
 
+func (m *MemberExpression) Pos() token.Position {
+	return m.Left.Pos()
+}
+
+func (m *MemberExpression) End() token.Position {
+	return m.Member.End()
+}
+
 func (e *PrefixExpression) TokenLiteral() string {
 	return e.Token.Literal
 }
@@ -165,6 +344,14 @@ func (e *PrefixExpression) String() string {
 	return "(" + e.Operator + " " + e.Right.String() + ")"
 }
 
+func (e *PrefixExpression) Pos() token.Position {
+	return e.Token.Start
+}
+
+func (e *PrefixExpression) End() token.Position {
+	return e.Right.End()
+}
+
 func (e *InfixExpression) TokenLiteral() string {
 	return e.Token.Literal
 }
@@ -173,6 +360,14 @@ func (e *InfixExpression) String() string {
 	return "(" + e.Left.String() + " " + e.Operator + " " + e.Right.String() + ")"
 }
 
+func (e *InfixExpression) Pos() token.Position {
+	return e.Left.Pos()
+}
+
+func (e *InfixExpression) End() token.Position {
+	return e.Right.End()
+}
+
 func (e *CallExpression) TokenLiteral() string {
 	return e.Token.Literal
 }
@@ -184,3 +379,46 @@ func (e *CallExpression) String() string {
 	}
 	return e.Function.String() + "(" + strings.Join(as, ", ") + ")"
 }
+
+func (e *CallExpression) Pos() token.Position {
+	return e.Function.Pos()
+}
+
+func (e *CallExpression) End() token.Position {
+	return e.Close.End
+}
+
+func (e *ConditionalExpression) TokenLiteral() string {
+	return e.Token.Literal
+}
+
+func (e *ConditionalExpression) String() string {
+	return "(" + e.Condition.String() + " ? " + e.Consequence.String() + " : " + e.Alternative.String() + ")"
+}
+
+func (e *ConditionalExpression) Pos() token.Position {
+	if e.Token.Type == token.If {
+		return e.Token.Start
+	}
+	return e.Condition.Pos()
+}
+
+func (e *ConditionalExpression) End() token.Position {
+	return e.Alternative.End()
+}
+
+func (e *Lambda) TokenLiteral() string {
+	return e.Token.Literal
+}
+
+func (e *Lambda) String() string {
+	return "(" + e.Parameter.String() + " => " + e.Body.String() + ")"
+}
+
+func (e *Lambda) Pos() token.Position {
+	return e.Parameter.Pos()
+}
+
+func (e *Lambda) End() token.Position {
+	return e.Body.End()
+}
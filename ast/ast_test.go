@@ -1,6 +1,7 @@
 package ast_test
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/stevecallear/mexl/ast"
@@ -41,6 +42,15 @@ func TestNode(t *testing.T) {
 			lit: "1.1",
 			str: "1.1",
 		},
+		{
+			name: "decimal literal",
+			sut: &ast.DecimalLiteral{
+				Token: token.Token{Type: token.Decimal, Literal: "1.10d"},
+				Value: big.NewRat(11, 10),
+			},
+			lit: "1.10d",
+			str: "1.10d",
+		},
 		{
 			name: "string literal",
 			sut: &ast.StringLiteral{
@@ -110,6 +120,22 @@ func TestNode(t *testing.T) {
 			lit: "]",
 			str: "([1, foo][1])",
 		},
+		{
+			name: "slice expression",
+			sut: &ast.SliceExpression{
+				Token: token.Token{Type: token.LBracket, Literal: "["},
+				Left: &ast.Identifier{
+					Token: token.Token{Type: token.Ident, Literal: "x"},
+					Value: "x",
+				},
+				High: &ast.IntegerLiteral{
+					Token: token.Token{Type: token.Int, Literal: "1"},
+					Value: 1,
+				},
+			},
+			lit: "[",
+			str: "(x[:1])",
+		},
 		{
 			name: "member expression",
 			sut: &ast.MemberExpression{
@@ -1,11 +1,23 @@
 package token
 
+import "strconv"
+
 type (
 	Type uint8
 
+	// Position represents a location in the source input.
+	// Offset is zero based, while Line and Column are one based.
+	Position struct {
+		Offset int
+		Line   int
+		Column int
+	}
+
 	Token struct {
 		Type    Type
 		Literal string
+		Start   Position
+		End     Position
 	}
 )
 
@@ -16,6 +28,7 @@ const (
 	Ident
 	Int
 	Float
+	Decimal
 	String
 	Plus
 
@@ -40,12 +53,91 @@ const (
 	StartsWith
 	EndsWith
 	In
+	Matches
+	Like
+	ILike
 
 	LParen
 	RParen
 	LBracket
 	RBracket
+	LBrace
+	RBrace
 
 	Stop
 	Comma
+	Colon
+	Question
+	Coalesce
+	Arrow
+
+	If
+	Then
+	Else
 )
+
+var names = map[Type]string{
+	Illegal:            "illegal token",
+	EOF:                "end of input",
+	Ident:              "identifier",
+	Int:                "integer",
+	Float:              "float",
+	Decimal:            "decimal",
+	String:             "string",
+	Plus:               "'+'",
+	True:               "'true'",
+	False:              "'false'",
+	Null:               "'null'",
+	Minus:              "'-'",
+	Asterisk:           "'*'",
+	Slash:              "'/'",
+	Percent:            "'%'",
+	And:                "'and'",
+	Or:                 "'or'",
+	Bang:               "'not'",
+	Equal:              "'eq'",
+	LessThan:           "'lt'",
+	GreaterThan:        "'gt'",
+	NotEqual:           "'ne'",
+	LessThanOrEqual:    "'le'",
+	GreaterThanOrEqual: "'ge'",
+	StartsWith:         "'sw'",
+	EndsWith:           "'ew'",
+	In:                 "'in'",
+	Matches:            "'matches'",
+	Like:               "'like'",
+	ILike:              "'ilike'",
+	LParen:             "'('",
+	RParen:             "')'",
+	LBracket:           "'['",
+	RBracket:           "']'",
+	LBrace:             "'{'",
+	RBrace:             "'}'",
+	Stop:               "'.'",
+	Comma:              "','",
+	Colon:              "':'",
+	Question:           "'?'",
+	Coalesce:           "'??'",
+	Arrow:              "'=>'",
+	If:                 "'if'",
+	Then:               "'then'",
+	Else:               "'else'",
+}
+
+// String returns the human readable name of the token type
+func (t Type) String() string {
+	if n, ok := names[t]; ok {
+		return n
+	}
+	return "token(" + strconv.Itoa(int(t)) + ")"
+}
+
+// String returns the position in line:column format
+func (p Position) String() string {
+	return strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Column)
+}
+
+// IsValid returns true if the position has been set
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
@@ -0,0 +1,112 @@
+package checker
+
+import "github.com/stevecallear/mexl/types"
+
+var (
+	// numeric accepts any number type
+	numeric = types.OneOf(types.SpecInteger, types.SpecFloat, types.SpecDecimal)
+
+	// numericOrArray accepts any number type or an array of numbers
+	numericOrArray = types.OneOf(types.SpecInteger, types.SpecFloat, types.SpecDecimal, types.ArrayOf(numeric))
+
+	// scalar accepts any type that can be converted to a number or boolean
+	scalar = types.OneOf(types.SpecString, types.SpecInteger, types.SpecFloat, types.SpecDecimal, types.SpecBoolean)
+)
+
+var builtIns = types.Schema{
+	"len": types.FuncOf(types.SpecInteger, types.OneOf(
+		types.SpecString,
+		types.ArrayOf(types.SpecAny),
+		types.MapOf(nil),
+	)),
+	"coalesce":   types.VariadicFuncOf(types.SpecAny, types.SpecAny),
+	"defined":    types.FuncOf(types.SpecBoolean, types.SpecAny),
+	"lower":      types.FuncOf(types.SpecString, types.SpecString),
+	"regexMatch": types.FuncOf(types.SpecBoolean, types.SpecString, types.SpecString),
+	"regexFind":  types.FuncOf(types.SpecString, types.SpecString, types.SpecString),
+	"now":        types.FuncOf(types.SpecTime),
+	"date":       types.FuncOf(types.SpecTime, types.SpecString),
+	"duration":   types.FuncOf(types.SpecDuration, types.SpecString),
+	"year":       types.FuncOf(types.SpecInteger, types.SpecTime),
+	"month":      types.FuncOf(types.SpecInteger, types.SpecTime),
+	"day":        types.FuncOf(types.SpecInteger, types.SpecTime),
+	"hour":       types.FuncOf(types.SpecInteger, types.SpecTime),
+	"minute":     types.FuncOf(types.SpecInteger, types.SpecTime),
+	"weekday":    types.FuncOf(types.SpecInteger, types.SpecTime),
+	"decimal":    types.FuncOf(types.SpecDecimal, types.OneOf(types.SpecString, types.SpecInteger, types.SpecFloat, types.SpecDecimal)),
+	"round":      types.VariadicFuncOf(numeric, numeric, types.SpecInteger),
+	"floor":      types.FuncOf(numeric, numeric),
+	"ceil":       types.FuncOf(numeric, numeric),
+	"abs":        types.FuncOf(numeric, numeric),
+	"min":        types.VariadicFuncOf(numeric, numericOrArray),
+	"max":        types.VariadicFuncOf(numeric, numericOrArray),
+	"pow":        types.FuncOf(numeric, numeric, numeric),
+	"sqrt":       types.FuncOf(types.OneOf(types.SpecInteger, types.SpecFloat), numeric),
+	"clamp":      types.FuncOf(numeric, numeric, numeric, numeric),
+	"sum":        types.FuncOf(numeric, types.ArrayOf(numeric)),
+	"avg":        types.FuncOf(numeric, types.ArrayOf(numeric)),
+	"string":     types.FuncOf(types.SpecString, types.SpecAny),
+	"int":        types.FuncOf(types.SpecInteger, scalar),
+	"float":      types.FuncOf(types.SpecFloat, scalar),
+	"bool":       types.FuncOf(types.SpecBoolean, scalar),
+	"type":       types.FuncOf(types.SpecString, types.SpecAny),
+	"isNull":     types.FuncOf(types.SpecBoolean, types.SpecAny),
+	"isBool":     types.FuncOf(types.SpecBoolean, types.SpecAny),
+	"isInt":      types.FuncOf(types.SpecBoolean, types.SpecAny),
+	"isFloat":    types.FuncOf(types.SpecBoolean, types.SpecAny),
+	"isDecimal":  types.FuncOf(types.SpecBoolean, types.SpecAny),
+	"isNumber":   types.FuncOf(types.SpecBoolean, types.SpecAny),
+	"isString":   types.FuncOf(types.SpecBoolean, types.SpecAny),
+	"isArray":    types.FuncOf(types.SpecBoolean, types.SpecAny),
+	"isMap":      types.FuncOf(types.SpecBoolean, types.SpecAny),
+	"isTime":     types.FuncOf(types.SpecBoolean, types.SpecAny),
+	"isDuration": types.FuncOf(types.SpecBoolean, types.SpecAny),
+	"upper":      types.FuncOf(types.SpecString, types.SpecString),
+	"keys":       types.FuncOf(types.ArrayOf(types.SpecString), types.MapOf(nil)),
+	"values":     types.FuncOf(types.ArrayOf(types.SpecAny), types.MapOf(nil)),
+	"has":        types.FuncOf(types.SpecBoolean, types.MapOf(nil), types.SpecString),
+	"first":      types.FuncOf(types.SpecAny, types.ArrayOf(types.SpecAny)),
+	"last":       types.FuncOf(types.SpecAny, types.ArrayOf(types.SpecAny)),
+	"sort":       types.FuncOf(types.ArrayOf(types.SpecAny), types.ArrayOf(types.SpecAny)),
+	"reverse":    types.FuncOf(types.ArrayOf(types.SpecAny), types.ArrayOf(types.SpecAny)),
+	"unique":     types.FuncOf(types.ArrayOf(types.SpecAny), types.ArrayOf(types.SpecAny)),
+	"flatten":    types.FuncOf(types.ArrayOf(types.SpecAny), types.ArrayOf(types.SpecAny)),
+	"concat":     types.VariadicFuncOf(types.ArrayOf(types.SpecAny), types.ArrayOf(types.SpecAny)),
+	"contains":   types.FuncOf(types.SpecBoolean, types.OneOf(types.SpecString, types.ArrayOf(types.SpecAny)), types.SpecAny),
+	"intersect":  types.FuncOf(types.ArrayOf(types.SpecAny), types.ArrayOf(types.SpecAny), types.ArrayOf(types.SpecAny)),
+	"union":      types.FuncOf(types.ArrayOf(types.SpecAny), types.ArrayOf(types.SpecAny), types.ArrayOf(types.SpecAny)),
+	"trim":       types.FuncOf(types.SpecString, types.SpecString),
+	"trimPrefix": types.FuncOf(types.SpecString, types.SpecString, types.SpecString),
+	"trimSuffix": types.FuncOf(types.SpecString, types.SpecString, types.SpecString),
+	"split":      types.FuncOf(types.ArrayOf(types.SpecString), types.SpecString, types.SpecString),
+	"join":       types.FuncOf(types.SpecString, types.ArrayOf(types.SpecString), types.SpecString),
+	"replace":    types.FuncOf(types.SpecString, types.SpecString, types.SpecString, types.SpecString),
+	"indexOf":    types.FuncOf(types.SpecInteger, types.SpecString, types.SpecString),
+	"substr":     types.VariadicFuncOf(types.SpecString, types.SpecString, types.SpecInteger, types.SpecInteger),
+	"repeat":     types.FuncOf(types.SpecString, types.SpecString, types.SpecInteger),
+	"padLeft":    types.VariadicFuncOf(types.SpecString, types.SpecString, types.SpecInteger, types.SpecString),
+	"format":     types.VariadicFuncOf(types.SpecString, types.SpecString, types.SpecAny),
+}
+
+// generics derive the result of built in functions from the argument specs
+var generics = map[string]func(args []*types.Spec) *types.Spec{
+	"first":   elemOf,
+	"last":    elemOf,
+	"sort":    sameAs,
+	"reverse": sameAs,
+	"unique":  sameAs,
+}
+
+func elemOf(args []*types.Spec) *types.Spec {
+	if args[0].Type == types.TypeArray && args[0].Elem != nil {
+		return args[0].Elem
+	}
+	return types.SpecAny
+}
+
+func sameAs(args []*types.Spec) *types.Spec {
+	if args[0].Type == types.TypeArray {
+		return args[0]
+	}
+	return types.ArrayOf(types.SpecAny)
+}
@@ -0,0 +1,653 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/stevecallear/mexl/ast"
+	"github.com/stevecallear/mexl/ast/token"
+	"github.com/stevecallear/mexl/types"
+)
+
+type (
+	// Checker infers the static type of an expression and reports type mismatches
+	Checker struct {
+		schema types.Schema
+		strict bool
+		locals []local
+	}
+
+	// Error represents a type error and the source span that caused it
+	Error struct {
+		Message string
+		Start   token.Position
+		End     token.Position
+	}
+)
+
+// New returns a new checker for the specified environment schema
+func New(s types.Schema) *Checker {
+	return &Checker{schema: s}
+}
+
+// NewStrict returns a new checker that reports identifiers and members that are not declared in the schema
+func NewStrict(s types.Schema) *Checker {
+	return &Checker{schema: s, strict: true}
+}
+
+// Check returns the inferred result type of the node
+func (c *Checker) Check(n ast.Node) (*types.Spec, error) {
+	return c.check(n)
+}
+
+func (c *Checker) check(n ast.Node) (*types.Spec, error) {
+	switch node := n.(type) {
+	case *ast.InfixExpression:
+		return c.checkInfixExpression(node)
+
+	case *ast.PrefixExpression:
+		return c.checkPrefixExpression(node)
+
+	case *ast.Boolean:
+		return types.SpecBoolean, nil
+
+	case *ast.Null:
+		return types.SpecNull, nil
+
+	case *ast.IntegerLiteral:
+		return types.SpecInteger, nil
+
+	case *ast.FloatLiteral:
+		return types.SpecFloat, nil
+
+	case *ast.DecimalLiteral:
+		return types.SpecDecimal, nil
+
+	case *ast.StringLiteral:
+		return types.SpecString, nil
+
+	case *ast.ArrayLiteral:
+		return c.checkArrayLiteral(node)
+
+	case *ast.MapLiteral:
+		return c.checkMapLiteral(node)
+
+	case *ast.Identifier:
+		return c.checkIdentifier(node)
+
+	case *ast.IndexExpression:
+		return c.checkIndexExpression(node)
+
+	case *ast.SliceExpression:
+		return c.checkSliceExpression(node)
+
+	case *ast.MemberExpression:
+		return c.checkMemberExpression(node)
+
+	case *ast.ConditionalExpression:
+		return c.checkConditionalExpression(node)
+
+	case *ast.CallExpression:
+		if name, fn, ok := iteration(node); ok {
+			return c.checkIteration(node, name, fn)
+		}
+		if err := c.expectIteration(node); err != nil {
+			return nil, err
+		}
+		return c.checkCallExpression(node)
+
+	case *ast.Lambda:
+		return nil, c.error(n, "unexpected lambda expression: %s", node)
+
+	default:
+		return nil, c.error(n, "invalid ast node: %T", n)
+	}
+}
+
+func (c *Checker) checkPrefixExpression(n *ast.PrefixExpression) (*types.Spec, error) {
+	r, err := c.check(n.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.Operator {
+	case "not", "!":
+		return types.SpecBoolean, nil
+
+	case "-":
+		if !is(r, types.TypeInteger, types.TypeFloat, types.TypeDecimal, types.TypeDuration) {
+			return nil, c.error(n, "invalid operand for negation: %s", r)
+		}
+		return r, nil
+
+	default:
+		return nil, c.error(n, "unknown prefix operator: %s", n.Operator)
+	}
+}
+
+func (c *Checker) checkInfixExpression(n *ast.InfixExpression) (*types.Spec, error) {
+	l, err := c.check(n.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := c.check(n.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.Operator {
+	case "+", "-", "*", "/", "%":
+		return c.checkArithmetic(n, l, r)
+
+	case "eq", "==", "ne", "!=":
+		return types.SpecBoolean, nil
+
+	case "lt", "<", "le", "<=", "gt", ">", "ge", ">=":
+		if isTemporal(l) || isTemporal(r) {
+			if !isComparableTemporal(l, r) && !isComparableTemporal(r, l) {
+				return nil, c.error(n, "invalid operands for %s: %s, %s", n.Operator, l, r)
+			}
+			return types.SpecBoolean, nil
+		}
+
+		if !is(l, types.TypeInteger, types.TypeFloat, types.TypeDecimal) || !is(r, types.TypeInteger, types.TypeFloat, types.TypeDecimal) || isNull(l) && isNull(r) {
+			return nil, c.error(n, "invalid operands for %s: %s, %s", n.Operator, l, r)
+		}
+		return types.SpecBoolean, nil
+
+	case "and", "&&", "or", "||":
+		if !is(l, types.TypeBoolean) || !is(r, types.TypeBoolean) {
+			return nil, c.error(n, "invalid operands for %s: %s, %s", n.Operator, l, r)
+		}
+		return types.SpecBoolean, nil
+
+	case "sw", "ew", "matches", "=~", "like", "ilike":
+		if !is(l, types.TypeString) || !is(r, types.TypeString) || isNull(l) && isNull(r) {
+			return nil, c.error(n, "invalid operands for %s: %s, %s", n.Operator, l, r)
+		}
+		return types.SpecBoolean, nil
+
+	case "??":
+		switch {
+		case isNull(l):
+			return r, nil
+		case isAny(l) || isAny(r):
+			return types.SpecAny, nil
+		case l.String() == r.String() || isNull(r):
+			return l, nil
+		default:
+			return types.OneOf(l, r), nil
+		}
+
+	case "in":
+		switch {
+		case is(r, types.TypeArray):
+		case is(r, types.TypeString) && is(l, types.TypeString):
+		default:
+			return nil, c.error(n, "invalid operands for in: %s, %s", l, r)
+		}
+		return types.SpecBoolean, nil
+
+	default:
+		return nil, c.error(n, "unknown infix operator: %s", n.Operator)
+	}
+}
+
+func (c *Checker) checkArithmetic(n *ast.InfixExpression, l, r *types.Spec) (*types.Spec, error) {
+	// nulls are coerced to the default value of the other operand type
+	if isNull(l) && !isNull(r) {
+		l = r
+	} else if isNull(r) && !isNull(l) {
+		r = l
+	}
+
+	if isTemporal(l) || isTemporal(r) {
+		if s, ok := temporalResult(n.Operator, l, r); ok {
+			return s, nil
+		}
+		return nil, c.error(n, "invalid operands for %s: %s, %s", n.Operator, l, r)
+	}
+
+	switch {
+	case n.Operator == "+" && is(l, types.TypeString) && is(r, types.TypeString) && !isNull(l):
+		if isAny(l) || isAny(r) {
+			return types.SpecAny, nil
+		}
+		return types.SpecString, nil
+
+	case n.Operator == "%" && is(l, types.TypeInteger) && is(r, types.TypeInteger) && !isNull(l):
+		if isAny(l) || isAny(r) {
+			return types.SpecAny, nil
+		}
+		return types.SpecInteger, nil
+
+	case n.Operator == "%" && (l.Type == types.TypeDecimal || r.Type == types.TypeDecimal) && is(l, types.TypeInteger, types.TypeFloat, types.TypeDecimal) && is(r, types.TypeInteger, types.TypeFloat, types.TypeDecimal):
+		if isAny(l) || isAny(r) {
+			return types.SpecAny, nil
+		}
+		return types.SpecDecimal, nil
+
+	case n.Operator != "%" && is(l, types.TypeInteger, types.TypeFloat) && is(r, types.TypeInteger, types.TypeFloat) && !isNull(l):
+		switch {
+		case isAny(l) || isAny(r):
+			return types.SpecAny, nil
+
+		case l.Type == types.TypeFloat || r.Type == types.TypeFloat:
+			return types.SpecFloat, nil
+
+		case l.Type == types.TypeInteger && r.Type == types.TypeInteger && n.Operator != "/":
+			return types.SpecInteger, nil
+
+		default:
+			// integer division results in a float if the result is inexact
+			return types.OneOf(types.SpecInteger, types.SpecFloat), nil
+		}
+
+	case n.Operator != "%" && is(l, types.TypeInteger, types.TypeFloat, types.TypeDecimal) && is(r, types.TypeInteger, types.TypeFloat, types.TypeDecimal) && !isNull(l):
+		switch {
+		case isAny(l) || isAny(r):
+			return types.SpecAny, nil
+
+		case l.Type == types.TypeDecimal || r.Type == types.TypeDecimal:
+			// integers and floats are converted to decimals
+			return types.SpecDecimal, nil
+
+		default:
+			return types.OneOf(types.SpecInteger, types.SpecFloat, types.SpecDecimal), nil
+		}
+
+	default:
+		return nil, c.error(n, "invalid operands for %s: %s, %s", n.Operator, l, r)
+	}
+}
+
+func (c *Checker) checkArrayLiteral(n *ast.ArrayLiteral) (*types.Spec, error) {
+	var elem *types.Spec
+
+	for _, e := range n.Elements {
+		s, err := c.check(e)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case elem == nil:
+			elem = s
+		case elem.Type == s.Type && elem.Union == nil && s.Union == nil:
+		case is(elem, types.TypeInteger, types.TypeFloat) && is(s, types.TypeInteger, types.TypeFloat) && !isAny(elem) && !isAny(s):
+			elem = types.SpecFloat
+		default:
+			elem = types.SpecAny
+		}
+	}
+
+	if elem == nil {
+		elem = types.SpecAny
+	}
+
+	return types.ArrayOf(elem), nil
+}
+
+func (c *Checker) checkConditionalExpression(n *ast.ConditionalExpression) (*types.Spec, error) {
+	cond, err := c.check(n.Condition)
+	if err != nil {
+		return nil, err
+	}
+
+	if !is(cond, types.TypeBoolean) {
+		return nil, c.error(n.Condition, "invalid condition type: %s", cond)
+	}
+
+	l, err := c.check(n.Consequence)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := c.check(n.Alternative)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case l.String() == r.String():
+		return l, nil
+	case isAny(l) || isAny(r):
+		return types.SpecAny, nil
+	default:
+		return types.OneOf(l, r), nil
+	}
+}
+
+func (c *Checker) checkMapLiteral(n *ast.MapLiteral) (*types.Spec, error) {
+	fields := make(map[string]*types.Spec, len(n.Keys))
+
+	for i, k := range n.Keys {
+		s, err := c.check(n.Values[i])
+		if err != nil {
+			return nil, err
+		}
+
+		switch kn := k.(type) {
+		case *ast.Identifier:
+			fields[kn.Value] = s
+		case *ast.StringLiteral:
+			fields[kn.Value] = s
+		default:
+			return nil, c.error(k, "invalid map key type: %T", k)
+		}
+	}
+
+	return types.MapOf(fields), nil
+}
+
+func (c *Checker) checkIdentifier(n *ast.Identifier) (*types.Spec, error) {
+	if s, ok := c.resolveLocal(n.Value); ok {
+		return s, nil
+	}
+
+	if s, ok := c.schema[n.Value]; ok {
+		return s, nil
+	}
+
+	if s, ok := builtIns[n.Value]; ok {
+		return s, nil
+	}
+
+	if c.strict {
+		cs := make([]string, 0, len(c.schema)+len(builtIns))
+		for k := range c.schema {
+			cs = append(cs, k)
+		}
+		for k := range builtIns {
+			cs = append(cs, k)
+		}
+
+		return nil, c.undeclared(n, "undeclared identifier: "+n.Value, n.Value, cs)
+	}
+
+	return types.SpecAny, nil
+}
+
+func (c *Checker) checkIndexExpression(n *ast.IndexExpression) (*types.Spec, error) {
+	l, err := c.check(n.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	i, err := c.check(n.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case isNull(l):
+		return types.SpecNull, nil
+
+	case is(l, types.TypeArray, types.TypeString) && isNull(i):
+		// a null index evaluates to null
+		return types.SpecNull, nil
+
+	case is(l, types.TypeArray) && is(i, types.TypeInteger) && !isNull(i):
+		if l.Type == types.TypeArray && l.Elem != nil {
+			return l.Elem, nil
+		}
+		return types.SpecAny, nil
+
+	case is(l, types.TypeString) && is(i, types.TypeInteger) && !isNull(i):
+		if isAny(l) {
+			return types.SpecAny, nil
+		}
+		return types.SpecString, nil
+
+	case is(l, types.TypeMap) && l.Union == nil && is(i, types.TypeString):
+		return c.checkMapIndex(n, l)
+
+	default:
+		return nil, c.error(n, "invalid index operation: %s[%s]", l, i)
+	}
+}
+
+// checkMapIndex returns the member spec if the key is a string literal
+func (c *Checker) checkMapIndex(n *ast.IndexExpression, l *types.Spec) (*types.Spec, error) {
+	k, ok := n.Index.(*ast.StringLiteral)
+	if !ok {
+		return types.SpecAny, nil
+	}
+
+	if s, ok := l.Member(k.Value); ok {
+		return s, nil
+	}
+
+	if c.strict {
+		cs := make([]string, 0, len(l.Fields))
+		for f := range l.Fields {
+			cs = append(cs, f)
+		}
+
+		return nil, c.undeclared(k, "undeclared member: "+k.Value, k.Value, cs)
+	}
+
+	return types.SpecNull, nil
+}
+
+func (c *Checker) checkSliceExpression(n *ast.SliceExpression) (*types.Spec, error) {
+	l, err := c.check(n.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	if !is(l, types.TypeArray, types.TypeString) {
+		return nil, c.error(n, "invalid slice operation: %s", l)
+	}
+
+	for _, b := range []ast.Node{n.Low, n.High} {
+		if b == nil {
+			continue
+		}
+
+		s, err := c.check(b)
+		if err != nil {
+			return nil, err
+		}
+
+		if !is(s, types.TypeInteger) {
+			return nil, c.error(b, "invalid slice index type: %s", s)
+		}
+	}
+
+	return l, nil
+}
+
+func (c *Checker) checkMemberExpression(n *ast.MemberExpression) (*types.Spec, error) {
+	l, err := c.check(n.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	ident, ok := n.Member.(*ast.Identifier)
+	if !ok {
+		return nil, c.error(n.Member, "invalid member type: %T", n.Member)
+	}
+
+	if !is(l, types.TypeMap) || l.Union != nil {
+		return nil, c.error(n, "invalid member access: %s.%s", l, ident.Value)
+	}
+
+	if s, ok := l.Member(ident.Value); ok {
+		return s, nil
+	}
+
+	if c.strict {
+		cs := make([]string, 0, len(l.Fields))
+		for k := range l.Fields {
+			cs = append(cs, k)
+		}
+
+		return nil, c.undeclared(ident, "undeclared member: "+ident.Value, ident.Value, cs)
+	}
+
+	// undeclared members evaluate to null at runtime
+	return types.SpecNull, nil
+}
+
+func (c *Checker) checkCallExpression(n *ast.CallExpression) (*types.Spec, error) {
+	fn, err := c.check(n.Function)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]*types.Spec, len(n.Arguments))
+	for i, a := range n.Arguments {
+		if args[i], err = c.check(a); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case isAny(fn):
+		return types.SpecAny, nil
+
+	case fn.Type != types.TypeFunc:
+		return nil, c.error(n.Function, "invalid function type: %s", fn)
+
+	case fn.Result == nil:
+		return types.SpecAny, nil
+	}
+
+	if err = c.checkArguments(n, fn, args); err != nil {
+		return nil, err
+	}
+
+	if ident, ok := n.Function.(*ast.Identifier); ok && fn == builtIns[ident.Value] {
+		if g, ok := generics[ident.Value]; ok {
+			return g(args), nil
+		}
+	}
+
+	return fn.Result, nil
+}
+
+func (c *Checker) checkArguments(n *ast.CallExpression, fn *types.Spec, args []*types.Spec) error {
+	np := len(fn.Params)
+
+	switch {
+	// a variadic signature without params has nothing to repeat, so takes no arguments
+	case (!fn.Variadic || np == 0) && len(args) != np:
+		return c.error(n, "%s: wrong number of arguments: %d, expected %d", n.Function, len(args), np)
+
+	case fn.Variadic && len(args) < np-1:
+		return c.error(n, "%s: wrong number of arguments: %d, expected at least %d", n.Function, len(args), np-1)
+	}
+
+	for i, a := range args {
+		p := fn.Params[min(i, np-1)]
+		if !p.Accepts(a) {
+			return c.error(n.Arguments[i], "%s: wrong argument type: %s, expected %s", n.Function, a, p)
+		}
+	}
+
+	return nil
+}
+
+func (c *Checker) undeclared(n ast.Node, msg, name string, candidates []string) error {
+	if s := suggest(name, candidates); s != "" {
+		return c.error(n, "%s, did you mean %s?", msg, s)
+	}
+	return c.error(n, "%s", msg)
+}
+
+func (c *Checker) error(n ast.Node, format string, args ...any) error {
+	return &Error{
+		Message: fmt.Sprintf(format, args...),
+		Start:   n.Pos(),
+		End:     n.End(),
+	}
+}
+
+// Error returns the error message prefixed with the start position
+func (e *Error) Error() string {
+	if !e.Start.IsValid() {
+		return e.Message
+	}
+	return e.Start.String() + ": " + e.Message
+}
+
+// is returns true if the spec could be one of the specified types at runtime
+func is(s *types.Spec, ts ...types.Type) bool {
+	if s.Union != nil {
+		for _, u := range s.Union {
+			if !is(u, ts...) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if s.Type == types.TypeAny || s.Type == types.TypeNull {
+		return true
+	}
+
+	for _, t := range ts {
+		if s.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+func isAny(s *types.Spec) bool {
+	return s.Type == types.TypeAny && s.Union == nil
+}
+
+func isNull(s *types.Spec) bool {
+	return s.Type == types.TypeNull
+}
+
+// isTemporal returns true if the spec is a time or duration
+func isTemporal(s *types.Spec) bool {
+	return s.Union == nil && (s.Type == types.TypeTime || s.Type == types.TypeDuration)
+}
+
+// isComparableTemporal returns true if the temporal spec l can be compared with r
+// Strings are converted to the temporal type at runtime.
+func isComparableTemporal(l, r *types.Spec) bool {
+	return isTemporal(l) && (r.Type == l.Type || is(r, types.TypeString) && r.Union == nil)
+}
+
+// temporalResult returns the result of an arithmetic operation on a time or duration
+func temporalResult(op string, l, r *types.Spec) (*types.Spec, bool) {
+	if isAny(l) || isAny(r) {
+		return types.SpecAny, true
+	}
+
+	lt, rt := l.Type, r.Type
+	number := func(t types.Type) bool {
+		return t == types.TypeInteger || t == types.TypeFloat
+	}
+
+	switch {
+	case op == "+" && (lt == types.TypeTime && rt == types.TypeDuration || lt == types.TypeDuration && rt == types.TypeTime):
+		return types.SpecTime, true
+
+	case op == "-" && lt == types.TypeTime && rt == types.TypeDuration:
+		return types.SpecTime, true
+
+	case op == "-" && lt == types.TypeTime && rt == types.TypeTime:
+		return types.SpecDuration, true
+
+	case (op == "+" || op == "-" || op == "%") && lt == types.TypeDuration && rt == types.TypeDuration:
+		return types.SpecDuration, true
+
+	case op == "/" && lt == types.TypeDuration && rt == types.TypeDuration:
+		return types.SpecFloat, true
+
+	case (op == "*" || op == "/") && lt == types.TypeDuration && number(rt):
+		return types.SpecDuration, true
+
+	case op == "*" && number(lt) && rt == types.TypeDuration:
+		return types.SpecDuration, true
+
+	default:
+		return nil, false
+	}
+}
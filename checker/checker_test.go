@@ -0,0 +1,293 @@
+package checker_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stevecallear/mexl/ast"
+	"github.com/stevecallear/mexl/checker"
+	"github.com/stevecallear/mexl/parser"
+	"github.com/stevecallear/mexl/types"
+)
+
+var schema = types.Schema{
+	"count": types.SpecInteger,
+	"price": types.SpecFloat,
+	"name":  types.SpecString,
+	"tags":  types.ArrayOf(types.SpecString),
+	"attrs": types.MapOf(nil),
+	"user": types.MapOf(map[string]*types.Spec{
+		"email": types.SpecString,
+		"age":   types.SpecInteger,
+		"address": types.MapOf(map[string]*types.Spec{
+			"city": types.SpecString,
+		}),
+	}),
+	"expiry":  types.SpecTime,
+	"ttl":     types.SpecDuration,
+	"amount":  types.SpecDecimal,
+	"reverse": types.FuncOf(types.SpecString, types.SpecString),
+	"concat":  types.VariadicFuncOf(types.SpecString, types.SpecString),
+	"join":    types.VariadicFuncOf(types.SpecString, types.SpecString, types.SpecString),
+}
+
+func TestChecker_Check(t *testing.T) {
+	tests := []struct {
+		input string
+		exp   string
+	}{
+		{"1", "INTEGER"},
+		{"1.1", "FLOAT"},
+		{`"a"`, "STRING"},
+		{"true", "BOOLEAN"},
+		{"null", "NULL"},
+		{"[]", "ARRAY<ANY>"},
+		{"[1, 2]", "ARRAY<INTEGER>"},
+		{"[1, 2.2]", "ARRAY<FLOAT>"},
+		{`[1, "a"]`, "ARRAY<ANY>"},
+		{"-1", "INTEGER"},
+		{"not 1", "BOOLEAN"},
+		{"1 + 2", "INTEGER"},
+		{"1 + 2.2", "FLOAT"},
+		{"null + 1", "INTEGER"},
+		{"4 / 2", "INTEGER|FLOAT"},
+		{"5 % 2", "INTEGER"},
+		{`"a" + "b"`, "STRING"},
+		{"count + 1", "INTEGER"},
+		{"price * count", "FLOAT"},
+		{"unknown + 1", "ANY"},
+		{"count gt 1", "BOOLEAN"},
+		{"count / 2 gt 1", "BOOLEAN"},
+		{`name eq "a"`, "BOOLEAN"},
+		{`name sw "a" and count lt 2`, "BOOLEAN"},
+		{`"a" in tags`, "BOOLEAN"},
+		{`name matches "^a"`, "BOOLEAN"},
+		{`name ilike "a%"`, "BOOLEAN"},
+		{`regexFind(name, "a+")`, "STRING"},
+		{`"a" in name`, "BOOLEAN"},
+		{"tags[0]", "STRING"},
+		{"tags[null]", "NULL"},
+		{"name[null]", "NULL"},
+		{"null[0]", "NULL"},
+		{"tags[1:]", "ARRAY<STRING>"},
+		{"name[0]", "STRING"},
+		{`user["age"]`, "INTEGER"},
+		{`user["unknown"]`, "NULL"},
+		{"user[name]", "ANY"},
+		{`attrs["x-y"]`, "ANY"},
+		{"name[:count]", "STRING"},
+		{"{}", "MAP{}"},
+		{`{a: 1, "b": name}`, "MAP{a: INTEGER, b: STRING}"},
+		{`{a: {b: 1}}.a.b`, "INTEGER"},
+		{"count gt 1 ? 1 : 2", "INTEGER"},
+		{`if count gt 1 then 1 else "a"`, "INTEGER|STRING"},
+		{"unknown ? unknown : 1", "ANY"},
+		{`name ?? "a"`, "STRING"},
+		{`null ?? 1`, "INTEGER"},
+		{`count ?? "a"`, "INTEGER|STRING"},
+		{`defined(user.age)`, "BOOLEAN"},
+		{"user", "MAP{address: MAP{city: STRING}, age: INTEGER, email: STRING}"},
+		{"user.address.city", "STRING"},
+		{"user.unknown", "NULL"},
+		{"attrs.x.y", "ANY"},
+		{"len(tags)", "INTEGER"},
+		{"len(user)", "INTEGER"},
+		{"len(null)", "INTEGER"},
+		{"lower(name)", "STRING"},
+		{"reverse(name)", "STRING"},
+		{`concat("a", "b", name)`, "STRING"},
+		{`concat()`, "STRING"},
+		{`join(",", "a", "b")`, "STRING"},
+		{"unknown(1)", "ANY"},
+		{"expiry + ttl", "TIME"},
+		{"ttl + expiry", "TIME"},
+		{"expiry - ttl", "TIME"},
+		{"expiry - now()", "DURATION"},
+		{"ttl * 2", "DURATION"},
+		{"ttl / ttl", "FLOAT"},
+		{"ttl % ttl", "DURATION"},
+		{"-ttl", "DURATION"},
+		{"unknown + ttl", "ANY"},
+		{`expiry lt "2024-01-01"`, "BOOLEAN"},
+		{`ttl gt duration("1h")`, "BOOLEAN"},
+		{"hour(expiry)", "INTEGER"},
+		{`split(name, ",")`, "ARRAY<STRING>"},
+		{`indexOf(name, "a") + 1`, "INTEGER"},
+		{`padLeft(trim(name), 3)`, "STRING"},
+		{`format("%s: %d", name, count)`, "STRING"},
+		{"keys(attrs)", "ARRAY<STRING>"},
+		{`has(user, "email")`, "BOOLEAN"},
+		{"first(tags)", "STRING"},
+		{"sort(tags)", "ARRAY<STRING>"},
+		{"unique([1, 2])", "ARRAY<INTEGER>"},
+		{"first(unknown)", "ANY"},
+		{"union(tags, [1])", "ARRAY<ANY>"},
+		{`contains(tags, "a") and contains(name, "a")`, "BOOLEAN"},
+		{"abs(count)", "INTEGER|FLOAT|DECIMAL"},
+		{"max(count, price, [1])", "INTEGER|FLOAT|DECIMAL"},
+		{"sum([1, 2]) gt 2", "BOOLEAN"},
+		{"int(name) + 1", "INTEGER"},
+		{"float(count)", "FLOAT"},
+		{"string(count) + name", "STRING"},
+		{`bool("true") and true`, "BOOLEAN"},
+		{`type(attrs) eq "MAP"`, "BOOLEAN"},
+		{"isNull(unknown) or isString(name)", "BOOLEAN"},
+		{"1.10d", "DECIMAL"},
+		{"amount * 2", "DECIMAL"},
+		{"amount % 2", "DECIMAL"},
+		{"0.5 + amount", "DECIMAL"},
+		{"-amount", "DECIMAL"},
+		{"amount ge 10", "BOOLEAN"},
+		{`decimal("1.10")`, "DECIMAL"},
+		{"round(amount, 2) + 1", "INTEGER|FLOAT|DECIMAL"},
+		{`any(tags, t => t sw "a")`, "BOOLEAN"},
+		{"all(unknown, x => x.y)", "BOOLEAN"},
+		{`filter(tags, t => t ne "a")`, "ARRAY<STRING>"},
+		{"map(tags, t => len(t))", "ARRAY<INTEGER>"},
+		{"map([[1]], a => map(a, b => b * 1.5))", "ARRAY<ARRAY<FLOAT>>"},
+		{"none(null, x => x)", "BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			act, err := checker.New(schema).Check(parse(tt.input))
+			if err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+
+			if act.String() != tt.exp {
+				t.Errorf("got %s, expected %s", act, tt.exp)
+			}
+		})
+	}
+}
+
+func TestChecker_Check_Errors(t *testing.T) {
+	tests := []struct {
+		input string
+		exp   string
+		start int
+	}{
+		{`"a" - 1`, `invalid operands for -: STRING, INTEGER`, 0},
+		{"1.1 % 2", "invalid operands for %: FLOAT, INTEGER", 0},
+		{"null + null", "invalid operands for +: NULL, NULL", 0},
+		{"true + 1", "invalid operands for +: BOOLEAN, INTEGER", 0},
+		{`-"a"`, "invalid operand for negation: STRING", 0},
+		{`1 lt "a"`, "invalid operands for lt: INTEGER, STRING", 0},
+		{"1 and true", "invalid operands for and: INTEGER, BOOLEAN", 0},
+		{"1 sw 2", "invalid operands for sw: INTEGER, INTEGER", 0},
+		{`count =~ "a"`, "invalid operands for =~: INTEGER, STRING", 0},
+		{`name like 1`, "invalid operands for like: STRING, INTEGER", 0},
+		{"1 in 2", "invalid operands for in: INTEGER, INTEGER", 0},
+		{"1 in name", "invalid operands for in: INTEGER, STRING", 0},
+		{`tags["a"]`, "invalid index operation: ARRAY<STRING>[STRING]", 0},
+		{"name.x", "invalid member access: STRING.x", 0},
+		{"count[1:]", "invalid slice operation: INTEGER", 0},
+		{"user[1]", "invalid index operation: MAP{address: MAP{city: STRING}, age: INTEGER, email: STRING}[INTEGER]", 0},
+		{`name["a"]`, "invalid index operation: STRING[STRING]", 0},
+		{`tags[:"a"]`, "invalid slice index type: STRING", 6},
+		{"count ? 1 : 2", "invalid condition type: INTEGER", 0},
+		{"expiry + expiry", "invalid operands for +: TIME, TIME", 0},
+		{"ttl - expiry", "invalid operands for -: DURATION, TIME", 0},
+		{"expiry lt ttl", "invalid operands for lt: TIME, DURATION", 0},
+		{"hour(ttl)", "hour: wrong argument type: DURATION, expected TIME", 5},
+		{"int(tags)", "int: wrong argument type: ARRAY<STRING>, expected STRING|INTEGER|FLOAT|DECIMAL|BOOLEAN", 4},
+		{"keys(tags)", "keys: wrong argument type: ARRAY<STRING>, expected MAP", 5},
+		{"first(name)", "first: wrong argument type: STRING, expected ARRAY<ANY>", 6},
+		{"sum(tags)", "sum: wrong argument type: ARRAY<STRING>, expected ARRAY<INTEGER|FLOAT|DECIMAL>", 4},
+		{"sqrt(name)", "sqrt: wrong argument type: STRING, expected INTEGER|FLOAT|DECIMAL", 5},
+		{"trim(1)", "trim: wrong argument type: INTEGER, expected STRING", 5},
+		{`repeat(name, "a")`, "repeat: wrong argument type: STRING, expected INTEGER", 13},
+		{"amount % name", "invalid operands for %: DECIMAL, STRING", 0},
+		{`amount + "a"`, "invalid operands for +: DECIMAL, STRING", 0},
+		{`round(amount, "a")`, "round: wrong argument type: STRING, expected INTEGER", 14},
+		{"len(1)", "len: wrong argument type: INTEGER, expected STRING|ARRAY<ANY>|MAP", 4},
+		{`lower("a", "b")`, "lower: wrong number of arguments: 2, expected 1", 0},
+		{`join()`, "join: wrong number of arguments: 0, expected at least 1", 0},
+		{`concat("a", 1)`, "concat: wrong argument type: INTEGER, expected STRING", 12},
+		{"name(1)", "invalid function type: STRING", 0},
+		{"count + user.address.city", "invalid operands for +: INTEGER, STRING", 0},
+		{"1 + (2 - true)", "invalid operands for -: INTEGER, BOOLEAN", 5},
+		{"any(name, x => true)", "any: wrong argument type: STRING, expected ARRAY<ANY>", 4},
+		{"filter(tags, t => len(t))", "filter: invalid predicate type: INTEGER", 18},
+		{"map(tags, t => t - 1)", "invalid operands for -: STRING, INTEGER", 15},
+		{"reverse(x => x)", "unexpected lambda expression: (x => x)", 8},
+		{"any(tags, 1)", "any: expected collection and lambda arguments", 0},
+		{"filter(tags)", "filter: expected collection and lambda arguments", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := checker.New(schema).Check(parse(tt.input))
+
+			var act *checker.Error
+			if !errors.As(err, &act) {
+				t.Fatalf("got %v, expected checker.Error", err)
+			}
+
+			if act.Message != tt.exp {
+				t.Errorf("got %s, expected %s", act.Message, tt.exp)
+			}
+
+			if act.Start.Offset != tt.start {
+				t.Errorf("got %d, expected %d", act.Start.Offset, tt.start)
+			}
+		})
+	}
+}
+
+func TestChecker_Check_Strict(t *testing.T) {
+	tests := []struct {
+		input string
+		exp   string
+		start int
+	}{
+		{"count + 1", "", 0},
+		{"len(user.address.city)", "", 0},
+		{"attrs.x.y", "", 0},
+		{"usr.email", "undeclared identifier: usr, did you mean user?", 0},
+		{"user.emial", "undeclared member: emial, did you mean email?", 5},
+		{"user.address.cty", "undeclared member: cty, did you mean city?", 13},
+		{"lowr(name)", "undeclared identifier: lowr, did you mean lower?", 0},
+		{"user.phone", "undeclared member: phone", 5},
+		{"x", "undeclared identifier: x", 0},
+		{"mn(1, 2)", "undeclared identifier: mn, did you mean min?", 0},
+		{"nm", "undeclared identifier: nm", 0},
+		{`user["emial"]`, "undeclared member: emial, did you mean email?", 5},
+		{"any(tags, t => len(t) gt count)", "", 0},
+		{"any(tags, t => len(x) gt 0)", "undeclared identifier: x", 19},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := checker.NewStrict(schema).Check(parse(tt.input))
+			if tt.exp == "" {
+				if err != nil {
+					t.Errorf("got %v, expected nil", err)
+				}
+				return
+			}
+
+			var act *checker.Error
+			if !errors.As(err, &act) {
+				t.Fatalf("got %v, expected checker.Error", err)
+			}
+
+			if act.Message != tt.exp {
+				t.Errorf("got %s, expected %s", act.Message, tt.exp)
+			}
+
+			if act.Start.Offset != tt.start {
+				t.Errorf("got %d, expected %d", act.Start.Offset, tt.start)
+			}
+		})
+	}
+}
+
+func parse(input string) ast.Node {
+	n, err := parser.New(input).Parse()
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
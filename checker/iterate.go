@@ -0,0 +1,99 @@
+package checker
+
+import (
+	"github.com/stevecallear/mexl/ast"
+	"github.com/stevecallear/mexl/types"
+)
+
+type local struct {
+	name string
+	spec *types.Spec
+}
+
+var iterators = map[string]bool{
+	"any":    true,
+	"all":    true,
+	"none":   true,
+	"filter": true,
+	"map":    true,
+}
+
+// iteration returns the operation name and lambda if the call is a collection operation
+func iteration(n *ast.CallExpression) (string, *ast.Lambda, bool) {
+	ident, ok := n.Function.(*ast.Identifier)
+	if !ok || len(n.Arguments) != 2 || !iterators[ident.Value] {
+		return "", nil, false
+	}
+
+	fn, ok := n.Arguments[1].(*ast.Lambda)
+	return ident.Value, fn, ok
+}
+
+// expectIteration returns an error if the call names a collection operation but is not of the form name(collection, lambda)
+// Identifiers declared in the schema and lambda parameters with the same name are checked as normal calls.
+func (c *Checker) expectIteration(n *ast.CallExpression) error {
+	ident, ok := n.Function.(*ast.Identifier)
+	if !ok || !iterators[ident.Value] {
+		return nil
+	}
+
+	if _, ok = c.schema[ident.Value]; ok {
+		return nil
+	}
+
+	if _, ok = c.resolveLocal(ident.Value); ok {
+		return nil
+	}
+
+	return c.error(n, "%s: expected collection and lambda arguments", ident.Value)
+}
+
+func (c *Checker) checkIteration(n *ast.CallExpression, name string, fn *ast.Lambda) (*types.Spec, error) {
+	coll, err := c.check(n.Arguments[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if !is(coll, types.TypeArray) {
+		return nil, c.error(n.Arguments[0], "%s: wrong argument type: %s, expected %s", name, coll, types.ArrayOf(types.SpecAny))
+	}
+
+	elem := types.SpecAny
+	if coll.Type == types.TypeArray && coll.Elem != nil {
+		elem = coll.Elem
+	}
+
+	c.locals = append(c.locals, local{name: fn.Parameter.Value, spec: elem})
+	body, err := c.check(fn.Body)
+	c.locals = c.locals[:len(c.locals)-1]
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "filter":
+		if !is(body, types.TypeBoolean) {
+			return nil, c.error(fn.Body, "%s: invalid predicate type: %s", name, body)
+		}
+		return types.ArrayOf(elem), nil
+
+	case "map":
+		return types.ArrayOf(body), nil
+
+	default:
+		if !is(body, types.TypeBoolean) {
+			return nil, c.error(fn.Body, "%s: invalid predicate type: %s", name, body)
+		}
+		return types.SpecBoolean, nil
+	}
+}
+
+// resolveLocal returns the spec of the innermost lambda parameter with the specified name
+func (c *Checker) resolveLocal(name string) (*types.Spec, bool) {
+	for i := len(c.locals) - 1; i >= 0; i-- {
+		if c.locals[i].name == name {
+			return c.locals[i].spec, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,50 @@
+package checker
+
+import "sort"
+
+// suggest returns the candidate closest to name by edit distance, or an empty string
+// if no candidate is similar enough to be a likely typo
+func suggest(name string, candidates []string) string {
+	sort.Strings(candidates)
+
+	best, bestDist := "", maxDistance(name)+1
+	for _, c := range candidates {
+		if d := distance(name, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+
+	return best
+}
+
+// maxDistance returns the maximum edit distance of a suggestion
+// Short names must retain at least one character, so a single character name is never matched.
+func maxDistance(name string) int {
+	return min(max(len(name)/3, 2), len([]rune(name))-1)
+}
+
+// distance returns the Levenshtein distance between a and b
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
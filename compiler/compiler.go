@@ -2,26 +2,68 @@ package compiler
 
 import (
 	"fmt"
+	"regexp"
 	"slices"
 
 	"github.com/stevecallear/mexl/ast"
+	"github.com/stevecallear/mexl/ast/token"
 	"github.com/stevecallear/mexl/types"
 	"github.com/stevecallear/mexl/vm"
 )
 
-type Compiler struct {
-	instructions vm.Instructions
-	constants    []types.Object
-	identifiers  []string
-}
+type (
+	Compiler struct {
+		instructions vm.Instructions
+		constants    []types.Object
+		identifiers  []string
+		functions    map[string]*function
+		funcs        []types.Object
+		patterns     []*regexp.Regexp
+		locals       []string
+		maxLocals    int
+		spans        map[int]vm.Span
+		calls        map[int]string
+		node         ast.Node
+		fold         bool
+		foldOpts     []vm.Option
+	}
+
+	// Option represents a compiler option
+	Option func(*Compiler)
+
+	// Error represents a compile error and the source span that caused it
+	Error struct {
+		Message string
+		Start   token.Position
+		End     token.Position
+	}
+)
 
 const jumpPlaceholder = 9999
 
-func New() *Compiler {
-	return &Compiler{
+func New(opts ...Option) *Compiler {
+	c := &Compiler{
 		instructions: vm.Instructions{},
 		constants:    []types.Object{},
 		identifiers:  []string{},
+		functions:    map[string]*function{},
+		spans:        map[int]vm.Span{},
+		calls:        map[int]string{},
+	}
+
+	for _, fn := range opts {
+		fn(c)
+	}
+
+	return c
+}
+
+// WithConstantFolding evaluates constant expressions at compile time
+// Expressions are evaluated using the specified VM options, and are not folded if evaluation fails.
+func WithConstantFolding(opts ...vm.Option) Option {
+	return func(c *Compiler) {
+		c.fold = true
+		c.foldOpts = opts
 	}
 }
 
@@ -34,10 +76,23 @@ func (c *Compiler) Compile(n ast.Node) (*vm.Program, error) {
 		Instructions: c.instructions,
 		Constants:    c.constants,
 		Identifiers:  c.identifiers,
+		Functions:    c.funcs,
+		Patterns:     c.patterns,
+		Locals:       c.maxLocals,
+		Spans:        c.spans,
+		Calls:        c.calls,
 	}, nil
 }
 
 func (c *Compiler) compile(n ast.Node) (err error) {
+	parent := c.node
+	c.node = n
+	defer func() { c.node = parent }()
+
+	if c.fold && isFoldable(n) && c.foldConstant(n) {
+		return nil
+	}
+
 	switch node := n.(type) {
 	case *ast.InfixExpression:
 		if err = c.compileInfixExpression(node); err != nil {
@@ -67,6 +122,10 @@ func (c *Compiler) compile(n ast.Node) (err error) {
 		obj := &types.Float{Value: node.Value}
 		c.emit(vm.OpConstant, c.addConstant(obj))
 
+	case *ast.DecimalLiteral:
+		obj := &types.Decimal{Value: node.Value}
+		c.emit(vm.OpConstant, c.addConstant(obj))
+
 	case *ast.StringLiteral:
 		obj := &types.String{Value: node.Value}
 		c.emit(vm.OpConstant, c.addConstant(obj))
@@ -77,8 +136,19 @@ func (c *Compiler) compile(n ast.Node) (err error) {
 		}
 		c.emit(vm.OpArray, len(node.Elements))
 
+	case *ast.MapLiteral:
+		if err = c.compileMapLiteral(node); err != nil {
+			return err
+		}
+
 	case *ast.Identifier:
-		c.emit(vm.OpGlobal, c.addIdentifier(node.Value))
+		if slot, ok := c.resolveLocal(node.Value); ok {
+			c.emit(vm.OpLocal, slot)
+		} else if _, ok := c.functions[node.Value]; ok {
+			c.emit(vm.OpFunction, c.addFunction(node.Value))
+		} else {
+			c.emit(vm.OpGlobal, c.addIdentifier(node.Value))
+		}
 
 	case *ast.IndexExpression:
 		if err = c.compile(node.Left); err != nil {
@@ -89,22 +159,51 @@ func (c *Compiler) compile(n ast.Node) (err error) {
 		}
 		c.emit(vm.OpIndex)
 
+	case *ast.SliceExpression:
+		if err = c.compileSliceExpression(node); err != nil {
+			return err
+		}
+
 	case *ast.MemberExpression:
 		if err = c.compileMemberExpression(node); err != nil {
 			return err
 		}
 
+	case *ast.ConditionalExpression:
+		if err = c.compileConditionalExpression(node); err != nil {
+			return err
+		}
+
 	case *ast.CallExpression:
+		if kind, fn, ok := iteration(node); ok {
+			return c.compileIteration(node, kind, fn)
+		}
+		if err = c.checkIteration(node); err != nil {
+			return err
+		}
+		if op, lit, ok := c.patternCall(node); ok {
+			return c.compilePatternCall(node, op, lit)
+		}
+		if err = c.checkCall(node); err != nil {
+			return err
+		}
+		if name, ok := c.builtInCall(node); ok {
+			return c.compileBuiltInCall(node, name)
+		}
 		if err = c.compile(node.Function); err != nil {
 			return err
 		}
 		if err = c.compileExpressions(node.Arguments); err != nil {
 			return err
 		}
-		c.emit(vm.OpCall, len(node.Arguments))
+		pos := c.emit(vm.OpCall, len(node.Arguments))
+		c.calls[pos] = node.Function.String()
+
+	case *ast.Lambda:
+		return c.error(n, "unexpected lambda expression: %s", node)
 
 	default:
-		return fmt.Errorf("invalid ast node: %T", n)
+		return c.error(n, "invalid ast node: %T", n)
 	}
 
 	return nil
@@ -132,7 +231,7 @@ func (c *Compiler) compilePrefixExpression(n *ast.PrefixExpression) (err error)
 		c.emit(vm.OpMinus)
 
 	default:
-		return fmt.Errorf("unknown prefix operator: %s", n.Operator)
+		return c.error(n, "unknown prefix operator: %s", n.Operator)
 	}
 
 	return nil
@@ -192,8 +291,20 @@ func (c *Compiler) compileInfixExpression(n *ast.InfixExpression) (err error) {
 	case "in":
 		op = vm.OpIn
 
+	case "like":
+		op = vm.OpLike
+
+	case "ilike":
+		op = vm.OpILike
+
+	case "matches", "=~":
+		return c.compileMatchExpression(n)
+
+	case "??":
+		return c.compileCoalesceExpression(n)
+
 	default:
-		return fmt.Errorf("unknown infix operator: %s", n.Operator)
+		return c.error(n, "unknown infix operator: %s", n.Operator)
 	}
 
 	return func() error {
@@ -215,6 +326,101 @@ func (c *Compiler) compileInfixExpression(n *ast.InfixExpression) (err error) {
 	}()
 }
 
+// compileCoalesceExpression compiles the right operand so that it is only evaluated if the left is null
+func (c *Compiler) compileCoalesceExpression(n *ast.InfixExpression) error {
+	if err := c.compile(n.Left); err != nil {
+		return err
+	}
+
+	jpos := c.emit(vm.OpJumpIfNotNull, jumpPlaceholder)
+	c.emit(vm.OpPop)
+
+	if err := c.compile(n.Right); err != nil {
+		return err
+	}
+
+	c.patchJump(jpos)
+	return nil
+}
+
+func (c *Compiler) compileConditionalExpression(n *ast.ConditionalExpression) error {
+	if err := c.compile(n.Condition); err != nil {
+		return err
+	}
+
+	// conditional jumps leave the condition on the stack, so it is popped on both branches
+	jfpos := c.emit(vm.OpJumpIfFalse, jumpPlaceholder)
+	c.emit(vm.OpPop)
+
+	if err := c.compile(n.Consequence); err != nil {
+		return err
+	}
+
+	jpos := c.emit(vm.OpJump, jumpPlaceholder)
+	c.patchJump(jfpos)
+	c.emit(vm.OpPop)
+
+	if err := c.compile(n.Alternative); err != nil {
+		return err
+	}
+
+	c.patchJump(jpos)
+	return nil
+}
+
+func (c *Compiler) compileMapLiteral(n *ast.MapLiteral) error {
+	seen := make(map[string]bool, len(n.Keys))
+
+	for i, k := range n.Keys {
+		var key string
+		switch kn := k.(type) {
+		case *ast.Identifier:
+			key = kn.Value
+		case *ast.StringLiteral:
+			key = kn.Value
+		default:
+			return c.error(k, "invalid map key type: %T", k)
+		}
+
+		if seen[key] {
+			return c.error(k, "duplicate map key: %s", key)
+		}
+		seen[key] = true
+
+		c.emit(vm.OpConstant, c.addConstant(types.NewString(key)))
+		if err := c.compile(n.Values[i]); err != nil {
+			return err
+		}
+	}
+
+	c.emit(vm.OpMap, len(n.Keys))
+	return nil
+}
+
+func (c *Compiler) compileSliceExpression(n *ast.SliceExpression) error {
+	if err := c.compile(n.Left); err != nil {
+		return err
+	}
+
+	var bounds int
+	if n.Low != nil {
+		if err := c.compile(n.Low); err != nil {
+			return err
+		}
+		bounds |= vm.SliceLow
+	}
+
+	if n.High != nil {
+		if err := c.compile(n.High); err != nil {
+			return err
+		}
+		bounds |= vm.SliceHigh
+	}
+
+	c.emit(vm.OpSlice, bounds)
+	return nil
+}
+
 func (c *Compiler) compileMemberExpression(n *ast.MemberExpression) error {
 	if err := c.compile(n.Left); err != nil {
 		return err
@@ -222,17 +428,50 @@ func (c *Compiler) compileMemberExpression(n *ast.MemberExpression) error {
 
 	ident, ok := n.Member.(*ast.Identifier)
 	if !ok {
-		return fmt.Errorf("invalid member type: %T", n.Member)
+		return c.error(n.Member, "invalid member type: %T", n.Member)
 	}
 
 	c.emit(vm.OpMember, c.addIdentifier(ident.Value))
 	return nil
 }
 
+// foldConstant evaluates the constant expression and emits the result
+// The expression is compiled as normal if evaluation fails, preserving the runtime error
+func (c *Compiler) foldConstant(n ast.Node) bool {
+	p, err := New().Compile(n)
+	if err != nil {
+		return false
+	}
+
+	obj, err := vm.New(p, nil, c.foldOpts...).Run()
+	if err != nil {
+		return false
+	}
+
+	switch obj.Type() {
+	case types.TypeBoolean:
+		if obj.(*types.Boolean).Value {
+			c.emit(vm.OpTrue)
+		} else {
+			c.emit(vm.OpFalse)
+		}
+
+	case types.TypeNull:
+		c.emit(vm.OpNull)
+
+	default:
+		c.emit(vm.OpConstant, c.addConstant(obj))
+	}
+
+	return true
+}
+
 func (c *Compiler) patchJump(pos int) {
 	op := vm.Opcode(c.instructions[pos])
-	ins := vm.Make(op, len(c.instructions))
+	c.replaceInstruction(pos, vm.Make(op, len(c.instructions)))
+}
 
+func (c *Compiler) replaceInstruction(pos int, ins vm.Instructions) {
 	for i := range ins {
 		c.instructions[pos+i] = ins[i]
 	}
@@ -244,6 +483,10 @@ func (c *Compiler) emit(op vm.Opcode, operands ...int) int {
 	ins := vm.Make(op, operands...)
 	c.instructions = append(c.instructions, ins...)
 
+	if c.node != nil {
+		c.spans[pos] = vm.Span{Start: c.node.Pos(), End: c.node.End()}
+	}
+
 	return pos
 }
 
@@ -252,6 +495,14 @@ func (c *Compiler) addConstant(obj types.Object) int {
 	return len(c.constants) - 1
 }
 
+func (c *Compiler) error(n ast.Node, format string, args ...any) error {
+	return &Error{
+		Message: fmt.Sprintf(format, args...),
+		Start:   n.Pos(),
+		End:     n.End(),
+	}
+}
+
 func (c *Compiler) addIdentifier(s string) int {
 	if i := slices.Index(c.identifiers, s); i >= 0 {
 		return i
@@ -260,3 +511,62 @@ func (c *Compiler) addIdentifier(s string) int {
 	c.identifiers = append(c.identifiers, s)
 	return len(c.identifiers) - 1
 }
+
+// Error returns the error message prefixed with the start position
+func (e *Error) Error() string {
+	if !e.Start.IsValid() {
+		return e.Message
+	}
+	return e.Start.String() + ": " + e.Message
+}
+
+// isFoldable returns true if the node is an operation on constant values
+func isFoldable(n ast.Node) bool {
+	switch node := n.(type) {
+	case *ast.PrefixExpression, *ast.InfixExpression, *ast.IndexExpression, *ast.SliceExpression, *ast.ArrayLiteral, *ast.MapLiteral, *ast.ConditionalExpression:
+		return isConstant(node)
+	default:
+		return false
+	}
+}
+
+func isConstant(n ast.Node) bool {
+	switch node := n.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.DecimalLiteral, *ast.StringLiteral, *ast.Boolean, *ast.Null:
+		return true
+
+	case *ast.PrefixExpression:
+		return isConstant(node.Right)
+
+	case *ast.InfixExpression:
+		return isConstant(node.Left) && isConstant(node.Right)
+
+	case *ast.IndexExpression:
+		return isConstant(node.Left) && isConstant(node.Index)
+
+	case *ast.SliceExpression:
+		return isConstant(node.Left) && (node.Low == nil || isConstant(node.Low)) && (node.High == nil || isConstant(node.High))
+
+	case *ast.ArrayLiteral:
+		for _, e := range node.Elements {
+			if !isConstant(e) {
+				return false
+			}
+		}
+		return true
+
+	case *ast.ConditionalExpression:
+		return isConstant(node.Condition) && isConstant(node.Consequence) && isConstant(node.Alternative)
+
+	case *ast.MapLiteral:
+		for _, v := range node.Values {
+			if !isConstant(v) {
+				return false
+			}
+		}
+		return true
+
+	default:
+		return false
+	}
+}
@@ -1,6 +1,7 @@
 package compiler_test
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/stevecallear/mexl/ast"
@@ -15,6 +16,7 @@ type (
 	testCase struct {
 		name string
 		node ast.Node
+		opts []compiler.Option
 		exp  expectation
 		err  bool
 	}
@@ -23,6 +25,7 @@ type (
 		instructions []vm.Instructions
 		constants    []any
 		identifiers  []string
+		patterns     []string
 	}
 )
 
@@ -170,6 +173,41 @@ func TestFloatArithmetic(t *testing.T) {
 	testCompiler(t, tests)
 }
 
+func TestDecimalArithmetic(t *testing.T) {
+	tests := []testCase{
+		{
+			name: "addition",
+			node: parse("1.10d + 2d"),
+			exp: expectation{
+				constants: []any{
+					&types.Decimal{Value: big.NewRat(11, 10)},
+					&types.Decimal{Value: big.NewRat(2, 1)},
+				},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpConstant, 0),
+					vm.Make(vm.OpConstant, 1),
+					vm.Make(vm.OpAdd),
+				},
+			},
+		},
+		{
+			name: "minus",
+			node: parse("-0.5d"),
+			exp: expectation{
+				constants: []any{
+					&types.Decimal{Value: big.NewRat(1, 2)},
+				},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpConstant, 0),
+					vm.Make(vm.OpMinus),
+				},
+			},
+		},
+	}
+
+	testCompiler(t, tests)
+}
+
 func TestBooleanExpressions(t *testing.T) {
 	tests := []testCase{
 		{
@@ -440,6 +478,30 @@ func TestBooleanExpressions(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "like",
+			node: parse(`"abc" like "a%"`),
+			exp: expectation{
+				constants: []any{"abc", "a%"},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpConstant, 0),
+					vm.Make(vm.OpConstant, 1),
+					vm.Make(vm.OpLike),
+				},
+			},
+		},
+		{
+			name: "ilike",
+			node: parse(`"abc" ilike "A%"`),
+			exp: expectation{
+				constants: []any{"abc", "A%"},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpConstant, 0),
+					vm.Make(vm.OpConstant, 1),
+					vm.Make(vm.OpILike),
+				},
+			},
+		},
 		{
 			name: "in",
 			node: parse(`2 in ["a", 2, 3.3]`),
@@ -533,6 +595,69 @@ func TestArrays(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "array slice",
+			node: parse("x[1:2]"),
+			exp: expectation{
+				constants:   []any{1, 2},
+				identifiers: []string{"x"},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpGlobal, 0),
+					vm.Make(vm.OpConstant, 0),
+					vm.Make(vm.OpConstant, 1),
+					vm.Make(vm.OpSlice, vm.SliceLow|vm.SliceHigh),
+				},
+			},
+		},
+		{
+			name: "array slice high",
+			node: parse("x[:2]"),
+			exp: expectation{
+				constants:   []any{2},
+				identifiers: []string{"x"},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpGlobal, 0),
+					vm.Make(vm.OpConstant, 0),
+					vm.Make(vm.OpSlice, vm.SliceHigh),
+				},
+			},
+		},
+	}
+
+	testCompiler(t, tests)
+}
+
+func TestMapLiterals(t *testing.T) {
+	tests := []testCase{
+		{
+			name: "empty",
+			node: parse("{}"),
+			exp: expectation{
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpMap, 0),
+				},
+			},
+		},
+		{
+			name: "map",
+			node: parse(`{a: 1, "b c": x}`),
+			exp: expectation{
+				constants:   []any{"a", 1, "b c"},
+				identifiers: []string{"x"},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpConstant, 0),
+					vm.Make(vm.OpConstant, 1),
+					vm.Make(vm.OpConstant, 2),
+					vm.Make(vm.OpGlobal, 0),
+					vm.Make(vm.OpMap, 2),
+				},
+			},
+		},
+		{
+			name: "duplicate key",
+			node: parse(`{a: 1, "a": 2}`),
+			err:  true,
+		},
 	}
 
 	testCompiler(t, tests)
@@ -577,9 +702,8 @@ func TestCallExpressions(t *testing.T) {
 				constants:   []any{"test"},
 				identifiers: []string{"upper"},
 				instructions: []vm.Instructions{
-					vm.Make(vm.OpGlobal, 0),
 					vm.Make(vm.OpConstant, 0),
-					vm.Make(vm.OpCall, 1),
+					vm.Make(vm.OpBuiltIn, 0, 1),
 				},
 			},
 		},
@@ -590,13 +714,107 @@ func TestCallExpressions(t *testing.T) {
 				constants:   []any{"test", "TEST"},
 				identifiers: []string{"upper"},
 				instructions: []vm.Instructions{
-					vm.Make(vm.OpGlobal, 0),
+					vm.Make(vm.OpConstant, 0),
+					vm.Make(vm.OpBuiltIn, 0, 1),
+					vm.Make(vm.OpConstant, 1),
+					vm.Make(vm.OpBuiltIn, 0, 1),
+					vm.Make(vm.OpEqual),
+				},
+			},
+		},
+	}
+
+	testCompiler(t, tests)
+}
+
+func TestFunctions(t *testing.T) {
+	fn := types.Func(func(args ...types.Object) (types.Object, error) {
+		return args[0], nil
+	})
+
+	opts := []compiler.Option{
+		compiler.WithFunction("fn", fn, types.FuncOf(types.SpecString, types.SpecString)),
+		compiler.WithFunction("dyn", fn, nil),
+	}
+
+	tests := []testCase{
+		{
+			name: "registered",
+			node: parse(`fn("a")`),
+			opts: opts,
+			exp: expectation{
+				constants: []any{"a"},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpFunction, 0),
+					vm.Make(vm.OpConstant, 0),
+					vm.Make(vm.OpCall, 1),
+				},
+			},
+		},
+		{
+			name: "function table",
+			node: parse(`dyn(1) + fn(x) + dyn(2)`),
+			opts: opts,
+			exp: expectation{
+				constants:   []any{1, 2},
+				identifiers: []string{"x"},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpFunction, 0),
 					vm.Make(vm.OpConstant, 0),
 					vm.Make(vm.OpCall, 1),
+					vm.Make(vm.OpFunction, 1),
 					vm.Make(vm.OpGlobal, 0),
+					vm.Make(vm.OpCall, 1),
+					vm.Make(vm.OpAdd),
+					vm.Make(vm.OpFunction, 0),
 					vm.Make(vm.OpConstant, 1),
 					vm.Make(vm.OpCall, 1),
-					vm.Make(vm.OpEqual),
+					vm.Make(vm.OpAdd),
+				},
+			},
+		},
+		{
+			name: "built in",
+			node: parse(`len("a")`),
+			opts: opts,
+			exp: expectation{
+				constants:   []any{"a"},
+				identifiers: []string{"len"},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpConstant, 0),
+					vm.Make(vm.OpBuiltIn, 0, 1),
+				},
+			},
+		},
+		{
+			name: "unknown function",
+			node: parse(`fm("a")`),
+			opts: opts,
+			err:  true,
+		},
+		{
+			name: "wrong number of arguments",
+			node: parse(`fn("a", "b")`),
+			opts: opts,
+			err:  true,
+		},
+		{
+			name: "wrong argument type",
+			node: parse(`fn(1)`),
+			opts: opts,
+			err:  true,
+		},
+		{
+			name: "no signature",
+			node: parse(`dyn(1, 2)`),
+			opts: opts,
+			exp: expectation{
+				constants: []any{1, 2},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpFunction, 0),
+					vm.Make(vm.OpConstant, 0),
+					vm.Make(vm.OpConstant, 1),
+					vm.Make(vm.OpCall, 2),
 				},
 			},
 		},
@@ -605,6 +823,31 @@ func TestCallExpressions(t *testing.T) {
 	testCompiler(t, tests)
 }
 
+func TestFunctions_ArgConversion(t *testing.T) {
+	var act types.Object
+	fn := types.Func(func(args ...types.Object) (types.Object, error) {
+		act = args[0]
+		return args[0], nil
+	})
+
+	p, err := compiler.New(compiler.WithFunction("half", fn, types.FuncOf(types.SpecFloat, types.SpecFloat))).Compile(parse(`half(x)`))
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	args := []types.Object{&types.Integer{Value: 3}}
+	if _, err = p.Functions[0].(types.Func)(args...); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	if _, ok := act.(*types.Float); !ok {
+		t.Errorf("got %T, expected *types.Float", act)
+	}
+	if _, ok := args[0].(*types.Integer); !ok {
+		t.Errorf("got %T, expected args to be unmodified", args[0])
+	}
+}
+
 func TestNull(t *testing.T) {
 	tests := []testCase{
 		{
@@ -655,6 +898,232 @@ func TestShortCircuiting(t *testing.T) {
 	testCompiler(t, tests)
 }
 
+func TestConditionals(t *testing.T) {
+	exp := expectation{
+		constants:   []any{1, 2},
+		identifiers: []string{"x"},
+		instructions: []vm.Instructions{
+			vm.Make(vm.OpGlobal, 0),
+			vm.Make(vm.OpJumpIfFalse, 12),
+			vm.Make(vm.OpPop),
+			vm.Make(vm.OpConstant, 0),
+			vm.Make(vm.OpJump, 16),
+			vm.Make(vm.OpPop),
+			vm.Make(vm.OpConstant, 1),
+		},
+	}
+
+	tests := []testCase{
+		{
+			name: "ternary",
+			node: parse("x ? 1 : 2"),
+			exp:  exp,
+		},
+		{
+			name: "if",
+			node: parse("if x then 1 else 2"),
+			exp:  exp,
+		},
+		{
+			name: "folded",
+			node: parse("true ? 1 : 2"),
+			opts: []compiler.Option{compiler.WithConstantFolding()},
+			exp: expectation{
+				constants: []any{1},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpConstant, 0),
+				},
+			},
+		},
+	}
+
+	testCompiler(t, tests)
+}
+
+func TestCoalesce(t *testing.T) {
+	tests := []testCase{
+		{
+			name: "coalesce",
+			node: parse("x ?? 1"),
+			exp: expectation{
+				constants:   []any{1},
+				identifiers: []string{"x"},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpGlobal, 0),
+					vm.Make(vm.OpJumpIfNotNull, 9),
+					vm.Make(vm.OpPop),
+					vm.Make(vm.OpConstant, 0),
+				},
+			},
+		},
+	}
+
+	testCompiler(t, tests)
+}
+
+func TestPatterns(t *testing.T) {
+	tests := []testCase{
+		{
+			name: "literal",
+			node: parse(`x matches "^a" or x =~ "^a"`),
+			exp: expectation{
+				identifiers: []string{"x"},
+				patterns:    []string{"^a"},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpGlobal, 0),
+					vm.Make(vm.OpMatchPattern, 0),
+					vm.Make(vm.OpJumpIfTrue, 14),
+					vm.Make(vm.OpGlobal, 0),
+					vm.Make(vm.OpMatchPattern, 0),
+					vm.Make(vm.OpOr),
+				},
+			},
+		},
+		{
+			name: "dynamic",
+			node: parse(`x matches y`),
+			exp: expectation{
+				identifiers: []string{"x", "y"},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpGlobal, 0),
+					vm.Make(vm.OpGlobal, 1),
+					vm.Make(vm.OpMatch),
+				},
+			},
+		},
+		{
+			name: "builtin",
+			node: parse(`regexFind(x, "a+")`),
+			exp: expectation{
+				identifiers: []string{"x"},
+				patterns:    []string{"a+"},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpGlobal, 0),
+					vm.Make(vm.OpFindPattern, 0),
+				},
+			},
+		},
+		{
+			name: "builtin dynamic",
+			node: parse(`regexMatch(x, y)`),
+			exp: expectation{
+				identifiers: []string{"regexMatch", "x", "y"},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpGlobal, 1),
+					vm.Make(vm.OpGlobal, 2),
+					vm.Make(vm.OpBuiltIn, 0, 2),
+				},
+			},
+		},
+		{
+			name: "invalid pattern",
+			node: parse(`x matches "("`),
+			err:  true,
+		},
+		{
+			name: "invalid builtin pattern",
+			node: parse(`regexMatch(x, "[")`),
+			err:  true,
+		},
+	}
+
+	testCompiler(t, tests)
+}
+
+func TestIterations(t *testing.T) {
+	tests := []testCase{
+		{
+			name: "any",
+			node: parse("any(x, o => o)"),
+			exp: expectation{
+				identifiers: []string{"x"},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpGlobal, 0),
+					vm.Make(vm.OpIter, int(vm.IterAny)),
+					vm.Make(vm.OpIterNext, 0, 14),
+					vm.Make(vm.OpLocal, 0),
+					vm.Make(vm.OpIterStep),
+					vm.Make(vm.OpJump, 4),
+					vm.Make(vm.OpIterEnd),
+				},
+			},
+		},
+		{
+			name: "nested",
+			node: parse("map(x, a => filter(a, b => a))"),
+			exp: expectation{
+				identifiers: []string{"x"},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpGlobal, 0),
+					vm.Make(vm.OpIter, int(vm.IterMap)),
+					vm.Make(vm.OpIterNext, 0, 27),
+					vm.Make(vm.OpLocal, 0),
+					vm.Make(vm.OpIter, int(vm.IterFilter)),
+					vm.Make(vm.OpIterNext, 1, 22),
+					vm.Make(vm.OpLocal, 0),
+					vm.Make(vm.OpIterStep),
+					vm.Make(vm.OpJump, 12),
+					vm.Make(vm.OpIterEnd),
+					vm.Make(vm.OpIterStep),
+					vm.Make(vm.OpJump, 4),
+					vm.Make(vm.OpIterEnd),
+				},
+			},
+		},
+		{
+			name: "parameter scope",
+			node: parse("any(x, o => o) or o"),
+			exp: expectation{
+				identifiers: []string{"x", "o"},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpGlobal, 0),
+					vm.Make(vm.OpIter, int(vm.IterAny)),
+					vm.Make(vm.OpIterNext, 0, 14),
+					vm.Make(vm.OpLocal, 0),
+					vm.Make(vm.OpIterStep),
+					vm.Make(vm.OpJump, 4),
+					vm.Make(vm.OpIterEnd),
+					vm.Make(vm.OpJumpIfTrue, 21),
+					vm.Make(vm.OpGlobal, 1),
+					vm.Make(vm.OpOr),
+				},
+			},
+		},
+		{
+			name: "unexpected lambda",
+			node: parse("len(x => x)"),
+			err:  true,
+		},
+		{
+			name: "missing lambda",
+			node: parse("any(x, 1)"),
+			err:  true,
+		},
+		{
+			name: "missing arguments",
+			node: parse("any(x)"),
+			err:  true,
+		},
+		{
+			name: "registered function",
+			node: parse("any(x)"),
+			opts: []compiler.Option{compiler.WithFunction("any", func(args ...types.Object) (types.Object, error) {
+				return args[0], nil
+			}, nil)},
+			exp: expectation{
+				identifiers: []string{"x"},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpFunction, 0),
+					vm.Make(vm.OpGlobal, 0),
+					vm.Make(vm.OpCall, 1),
+				},
+			},
+		},
+	}
+
+	testCompiler(t, tests)
+}
+
 func TestMapAccess(t *testing.T) {
 	tests := []testCase{
 		{
@@ -684,6 +1153,84 @@ func TestMapAccess(t *testing.T) {
 	testCompiler(t, tests)
 }
 
+func TestConstantFolding(t *testing.T) {
+	fold := []compiler.Option{compiler.WithConstantFolding()}
+
+	tests := []testCase{
+		{
+			name: "arithmetic",
+			node: parse("1 + 2 * 3"),
+			opts: fold,
+			exp: expectation{
+				constants: []any{7},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpConstant, 0),
+				},
+			},
+		},
+		{
+			name: "boolean",
+			node: parse("1 lt 2 and not false"),
+			opts: fold,
+			exp: expectation{
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpTrue),
+				},
+			},
+		},
+		{
+			name: "null",
+			node: parse("-null"),
+			opts: fold,
+			exp: expectation{
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpNull),
+				},
+			},
+		},
+		{
+			name: "partial",
+			node: parse(`x + (2 * 3)`),
+			opts: fold,
+			exp: expectation{
+				constants:   []any{6},
+				identifiers: []string{"x"},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpGlobal, 0),
+					vm.Make(vm.OpConstant, 0),
+					vm.Make(vm.OpAdd),
+				},
+			},
+		},
+		{
+			name: "runtime error",
+			node: parse(`"a" - 1`),
+			opts: fold,
+			exp: expectation{
+				constants: []any{"a", 1},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpConstant, 0),
+					vm.Make(vm.OpConstant, 1),
+					vm.Make(vm.OpSubtract),
+				},
+			},
+		},
+		{
+			name: "literal",
+			node: parse("1"),
+			opts: fold,
+			exp: expectation{
+				constants: []any{1},
+				instructions: []vm.Instructions{
+					vm.Make(vm.OpConstant, 0),
+				},
+			},
+		},
+	}
+
+	testCompiler(t, tests)
+}
+
 func TestErrors(t *testing.T) {
 	tests := []testCase{
 		{
@@ -917,12 +1464,41 @@ func TestErrors(t *testing.T) {
 	testCompiler(t, tests)
 }
 
+func TestSpans(t *testing.T) {
+	p, err := compiler.New().Compile(parse(`x + lower("a")`))
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	tests := []struct {
+		pos   int
+		start int
+		end   int
+	}{
+		{0, 0, 1},   // OpGlobal x
+		{2, 10, 13}, // OpConstant "a"
+		{5, 4, 14},  // OpBuiltIn lower
+		{8, 0, 14},  // OpAdd
+	}
+
+	for _, tt := range tests {
+		s, ok := p.Spans[tt.pos]
+		if !ok {
+			t.Errorf("got no span at %d, expected span", tt.pos)
+			continue
+		}
+		if s.Start.Offset != tt.start || s.End.Offset != tt.end {
+			t.Errorf("got %d-%d at %d, expected %d-%d", s.Start.Offset, s.End.Offset, tt.pos, tt.start, tt.end)
+		}
+	}
+}
+
 func testCompiler(t *testing.T, tests []testCase) {
 	t.Helper()
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c := compiler.New()
+			c := compiler.New(tt.opts...)
 			p, err := c.Compile(tt.node)
 			if err != nil && !tt.err {
 				t.Fatalf("got %v, expected nil", err)
@@ -937,6 +1513,12 @@ func testCompiler(t *testing.T, tests []testCase) {
 			assertInstructions(t, p.Instructions, tt.exp.instructions)
 			assertConstants(t, p.Constants, tt.exp.constants)
 			assertSymbols(t, p.Identifiers, tt.exp.identifiers)
+
+			patterns := make([]string, len(p.Patterns))
+			for i, re := range p.Patterns {
+				patterns[i] = re.String()
+			}
+			assertSymbols(t, patterns, tt.exp.patterns)
 		})
 	}
 }
@@ -998,6 +1580,10 @@ func assertObject(t *testing.T, act types.Object, exp any) {
 		assertBooleanObject(t, act, exp)
 	case string:
 		assertStringObject(t, act, exp)
+	case types.Object:
+		if !act.Equal(exp) {
+			t.Errorf("got %s, expected %s", act.Inspect(), exp.Inspect())
+		}
 	default:
 		t.Errorf("invalid assertion type: %T", exp)
 	}
@@ -1084,3 +1670,11 @@ func (n *invalidNode) TokenLiteral() string {
 func (n *invalidNode) String() string {
 	return "invalid"
 }
+
+func (n *invalidNode) Pos() token.Position {
+	return token.Position{}
+}
+
+func (n *invalidNode) End() token.Position {
+	return token.Position{}
+}
@@ -0,0 +1,213 @@
+package compiler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/stevecallear/mexl/ast"
+	"github.com/stevecallear/mexl/checker"
+	"github.com/stevecallear/mexl/types"
+	"github.com/stevecallear/mexl/vm"
+)
+
+type function struct {
+	fn    types.Object
+	spec  *types.Spec
+	index int
+}
+
+// WithFunction registers a function that is resolved at compile time
+// If a signature is specified, call arity and argument types are validated against it.
+// Once a function is registered, calls to unknown functions are reported as compile errors.
+func WithFunction(name string, fn types.Func, sig *types.Spec) Option {
+	return func(c *Compiler) {
+		c.functions[name] = &function{
+			fn:    bind(name, fn, sig),
+			spec:  sig,
+			index: -1,
+		}
+	}
+}
+
+// WithContextFunction registers a function that receives the context of the current run
+// See WithFunction for details of signature validation.
+func WithContextFunction(name string, fn types.ContextFunc, sig *types.Spec) Option {
+	return func(c *Compiler) {
+		c.functions[name] = &function{
+			fn:    bindContext(name, fn, sig),
+			spec:  sig,
+			index: -1,
+		}
+	}
+}
+
+// checkCall validates the call against the signature of the registered function
+func (c *Compiler) checkCall(n *ast.CallExpression) error {
+	ident, ok := n.Function.(*ast.Identifier)
+	if !ok || len(c.functions) < 1 {
+		return nil
+	}
+
+	if _, ok = c.resolveLocal(ident.Value); ok {
+		// lambda parameters are resolved at runtime
+		return nil
+	}
+
+	f, ok := c.functions[ident.Value]
+	if !ok {
+		if vm.IsBuiltIn(ident.Value) {
+			return nil
+		}
+		return c.error(n.Function, "unknown function: %s", ident.Value)
+	}
+
+	if f.spec == nil {
+		return nil
+	}
+
+	if err := checkArity(ident.Value, f.spec, len(n.Arguments)); err != nil {
+		return c.error(n, "%s", err.Error())
+	}
+
+	for i, a := range n.Arguments {
+		s, err := checker.New(nil).Check(a)
+		if err != nil {
+			// invalid arguments are reported when the argument is compiled or run
+			continue
+		}
+
+		if p := param(f.spec, i); !p.Accepts(s) {
+			return c.error(a, "%s: wrong argument type: %s, expected %s", ident.Value, s, p)
+		}
+	}
+
+	return nil
+}
+
+// builtInCall returns the function name if the call is to a built in function
+// Lambda parameters and registered functions with the same name are called as normal.
+func (c *Compiler) builtInCall(n *ast.CallExpression) (string, bool) {
+	ident, ok := n.Function.(*ast.Identifier)
+	if !ok || !vm.IsBuiltIn(ident.Value) {
+		return "", false
+	}
+
+	if _, ok = c.functions[ident.Value]; ok {
+		return "", false
+	}
+
+	if _, ok = c.resolveLocal(ident.Value); ok {
+		return "", false
+	}
+
+	return ident.Value, true
+}
+
+// compileBuiltInCall compiles a call that is resolved by name when it is executed
+// This allows the VM to pass args to built in functions without copying them.
+func (c *Compiler) compileBuiltInCall(n *ast.CallExpression, name string) error {
+	idx := c.addIdentifier(name)
+
+	if err := c.compileExpressions(n.Arguments); err != nil {
+		return err
+	}
+
+	pos := c.emit(vm.OpBuiltIn, idx, len(n.Arguments))
+	c.calls[pos] = name
+	return nil
+}
+
+func (c *Compiler) addFunction(name string) int {
+	f := c.functions[name]
+	if f.index < 0 {
+		c.funcs = append(c.funcs, f.fn)
+		f.index = len(c.funcs) - 1
+	}
+	return f.index
+}
+
+// bind returns a function that validates runtime arguments against the signature
+func bind(name string, fn types.Func, sig *types.Spec) types.Func {
+	if sig == nil {
+		return fn
+	}
+
+	return func(args ...types.Object) (types.Object, error) {
+		args, err := validate(name, sig, args)
+		if err != nil {
+			return nil, err
+		}
+		return fn(args...)
+	}
+}
+
+// bindContext returns a context function that validates runtime arguments against the signature
+func bindContext(name string, fn types.ContextFunc, sig *types.Spec) types.ContextFunc {
+	if sig == nil {
+		return fn
+	}
+
+	return func(ctx context.Context, args ...types.Object) (types.Object, error) {
+		args, err := validate(name, sig, args)
+		if err != nil {
+			return nil, err
+		}
+		return fn(ctx, args...)
+	}
+}
+
+// validate validates the arguments, converting numbers where a float or decimal parameter is expected
+// The caller's args are not modified; if a conversion is required, a copy is returned.
+func validate(name string, sig *types.Spec, args []types.Object) ([]types.Object, error) {
+	if err := checkArity(name, sig, len(args)); err != nil {
+		return nil, err
+	}
+
+	out := args
+	for i, a := range args {
+		p := param(sig, i)
+		if !p.Matches(a) {
+			return nil, fmt.Errorf("%s: wrong argument type: %s, expected %s", name, a.Type(), p)
+		}
+
+		var c types.Object
+		switch {
+		case p.Type == types.TypeFloat && a.Type() == types.TypeInteger:
+			c = &types.Float{Value: float64(a.(*types.Integer).Value)}
+
+		case p.Type == types.TypeDecimal && a.Type() != types.TypeNull && a.Type() != types.TypeDecimal:
+			c, _ = types.Convert(a, p.Type)
+
+		default:
+			continue
+		}
+
+		if &out[0] == &args[0] {
+			out = slices.Clone(args)
+		}
+		out[i] = c
+	}
+
+	return out, nil
+}
+
+func checkArity(name string, sig *types.Spec, n int) error {
+	np := len(sig.Params)
+
+	switch {
+	// a variadic signature without params has nothing to repeat, so takes no arguments
+	case (!sig.Variadic || np == 0) && n != np:
+		return fmt.Errorf("%s: wrong number of arguments: %d, expected %d", name, n, np)
+
+	case sig.Variadic && n < np-1:
+		return fmt.Errorf("%s: wrong number of arguments: %d, expected at least %d", name, n, np-1)
+
+	default:
+		return nil
+	}
+}
+
+func param(sig *types.Spec, i int) *types.Spec {
+	return sig.Params[min(i, len(sig.Params)-1)]
+}
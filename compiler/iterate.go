@@ -0,0 +1,84 @@
+package compiler
+
+import (
+	"github.com/stevecallear/mexl/ast"
+	"github.com/stevecallear/mexl/vm"
+)
+
+// iteration returns the iteration kind and lambda if the call is a collection operation
+func iteration(n *ast.CallExpression) (vm.IterKind, *ast.Lambda, bool) {
+	ident, ok := n.Function.(*ast.Identifier)
+	if !ok || len(n.Arguments) != 2 {
+		return 0, nil, false
+	}
+
+	kind, ok := vm.LookupIterKind(ident.Value)
+	if !ok {
+		return 0, nil, false
+	}
+
+	fn, ok := n.Arguments[1].(*ast.Lambda)
+	return kind, fn, ok
+}
+
+// checkIteration returns an error if the call names a collection operation but is not of the form name(collection, lambda)
+// Registered functions and lambda parameters with the same name are called as normal.
+func (c *Compiler) checkIteration(n *ast.CallExpression) error {
+	ident, ok := n.Function.(*ast.Identifier)
+	if !ok {
+		return nil
+	}
+
+	if _, ok = vm.LookupIterKind(ident.Value); !ok {
+		return nil
+	}
+
+	if _, ok = c.functions[ident.Value]; ok {
+		return nil
+	}
+
+	if _, ok = c.resolveLocal(ident.Value); ok {
+		return nil
+	}
+
+	return c.error(n, "%s: expected collection and lambda arguments", ident.Value)
+}
+
+// compileIteration compiles a loop that evaluates the lambda body for each element of the collection
+// The lambda parameter is assigned to a local slot for the duration of the body.
+func (c *Compiler) compileIteration(n *ast.CallExpression, kind vm.IterKind, fn *ast.Lambda) error {
+	if err := c.compile(n.Arguments[0]); err != nil {
+		return err
+	}
+
+	c.emit(vm.OpIter, int(kind))
+
+	slot := len(c.locals)
+	c.locals = append(c.locals, fn.Parameter.Value)
+	c.maxLocals = max(c.maxLocals, len(c.locals))
+
+	start := c.emit(vm.OpIterNext, slot, jumpPlaceholder)
+
+	if err := c.compile(fn.Body); err != nil {
+		return err
+	}
+
+	c.locals = c.locals[:slot]
+
+	c.emit(vm.OpIterStep)
+	c.emit(vm.OpJump, start)
+	c.replaceInstruction(start, vm.Make(vm.OpIterNext, slot, len(c.instructions)))
+	c.emit(vm.OpIterEnd)
+
+	return nil
+}
+
+// resolveLocal returns the slot of the innermost lambda parameter with the specified name
+func (c *Compiler) resolveLocal(name string) (int, bool) {
+	for i := len(c.locals) - 1; i >= 0; i-- {
+		if c.locals[i] == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
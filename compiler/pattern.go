@@ -0,0 +1,85 @@
+package compiler
+
+import (
+	"regexp"
+
+	"github.com/stevecallear/mexl/ast"
+	"github.com/stevecallear/mexl/vm"
+)
+
+var patternFuncs = map[string]vm.Opcode{
+	"regexMatch": vm.OpMatchPattern,
+	"regexFind":  vm.OpFindPattern,
+}
+
+// compileMatchExpression compiles the match operator, precompiling literal patterns
+func (c *Compiler) compileMatchExpression(n *ast.InfixExpression) error {
+	if err := c.compile(n.Left); err != nil {
+		return err
+	}
+
+	if lit, ok := n.Right.(*ast.StringLiteral); ok {
+		return c.compilePattern("matches", vm.OpMatchPattern, lit)
+	}
+
+	if err := c.compile(n.Right); err != nil {
+		return err
+	}
+
+	c.emit(vm.OpMatch)
+	return nil
+}
+
+// patternCall returns the pattern opcode and literal if the call is a built in regexp function with a literal pattern
+func (c *Compiler) patternCall(n *ast.CallExpression) (vm.Opcode, *ast.StringLiteral, bool) {
+	ident, ok := n.Function.(*ast.Identifier)
+	if !ok || len(n.Arguments) != 2 {
+		return 0, nil, false
+	}
+
+	op, ok := patternFuncs[ident.Value]
+	if !ok {
+		return 0, nil, false
+	}
+
+	if _, ok := c.functions[ident.Value]; ok {
+		return 0, nil, false
+	}
+	if _, ok := c.resolveLocal(ident.Value); ok {
+		return 0, nil, false
+	}
+
+	lit, ok := n.Arguments[1].(*ast.StringLiteral)
+	return op, lit, ok
+}
+
+func (c *Compiler) compilePatternCall(n *ast.CallExpression, op vm.Opcode, lit *ast.StringLiteral) error {
+	if err := c.compile(n.Arguments[0]); err != nil {
+		return err
+	}
+
+	return c.compilePattern(n.Function.String(), op, lit)
+}
+
+// compilePattern emits the pattern instruction, recording the operator or function name for runtime errors
+func (c *Compiler) compilePattern(name string, op vm.Opcode, lit *ast.StringLiteral) error {
+	re, err := regexp.Compile(lit.Value)
+	if err != nil {
+		return c.error(lit, "invalid pattern: %v", err)
+	}
+
+	pos := c.emit(op, c.addPattern(re))
+	c.calls[pos] = name
+	return nil
+}
+
+func (c *Compiler) addPattern(re *regexp.Regexp) int {
+	for i, p := range c.patterns {
+		if p.String() == re.String() {
+			return i
+		}
+	}
+
+	c.patterns = append(c.patterns, re)
+	return len(c.patterns) - 1
+}
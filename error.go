@@ -0,0 +1,103 @@
+package mexl
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/stevecallear/mexl/ast/token"
+	"github.com/stevecallear/mexl/checker"
+	"github.com/stevecallear/mexl/compiler"
+	"github.com/stevecallear/mexl/parser"
+	"github.com/stevecallear/mexl/vm"
+)
+
+// Error represents a parse, compile or runtime error and the source span that caused it
+type Error struct {
+	Message string
+	Start   token.Position
+	End     token.Position
+	Source  string
+	Err     error
+}
+
+// Error returns the error message prefixed with the start position
+func (e *Error) Error() string {
+	if !e.Start.IsValid() {
+		return e.Message
+	}
+	return e.Start.String() + ": " + e.Message
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Snippet returns the source line containing the error, annotated with carets beneath the error span
+func (e *Error) Snippet() string {
+	if !e.Start.IsValid() {
+		return ""
+	}
+
+	lines := strings.Split(e.Source, "\n")
+	if e.Start.Line > len(lines) {
+		return ""
+	}
+
+	line := strings.TrimRight(lines[e.Start.Line-1], "\r")
+	start := min(e.Start.Column-1, len(line))
+
+	end := len(line)
+	if e.End.Line == e.Start.Line {
+		end = min(e.End.Column-1, end)
+	}
+
+	num := strconv.Itoa(e.Start.Line)
+	gutter := strings.Repeat(" ", len(num))
+
+	var b strings.Builder
+	b.WriteString(num + " | " + line + "\n")
+	b.WriteString(gutter + " | ")
+
+	for _, ch := range line[:start] {
+		if ch == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+
+	b.WriteString(strings.Repeat("^", max(end-start, 1)))
+	return b.String()
+}
+
+func newError(source string, err error) error {
+	var (
+		perr *parser.Error
+		terr *checker.Error
+		cerr *compiler.Error
+		verr *vm.Error
+	)
+
+	e := &Error{Source: source, Err: err}
+
+	switch {
+	case errors.As(err, &perr):
+		e.Message, e.Start, e.End = perr.Message, perr.Start, perr.End
+
+	case errors.As(err, &terr):
+		e.Message, e.Start, e.End = terr.Message, terr.Start, terr.End
+
+	case errors.As(err, &cerr):
+		e.Message, e.Start, e.End = cerr.Message, cerr.Start, cerr.End
+
+	case errors.As(err, &verr):
+		e.Message, e.Start, e.End = verr.Err.Error(), verr.Start, verr.End
+
+	default:
+		return err
+	}
+
+	return e
+}
@@ -1,42 +1,126 @@
 package mexl
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/stevecallear/mexl/checker"
 	"github.com/stevecallear/mexl/compiler"
 	"github.com/stevecallear/mexl/parser"
 	"github.com/stevecallear/mexl/types"
 	"github.com/stevecallear/mexl/vm"
 )
 
+// ErrInputTooLong is returned by Compile if the input exceeds the maximum length
+var ErrInputTooLong = errors.New("input too long")
+
 // Eval compiles and runs the input
-func Eval(input string, env map[string]any) (any, error) {
-	p, err := Compile(input)
+func Eval(input string, env map[string]any, opts ...Option) (any, error) {
+	p, err := Compile(input, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return Run(p, env)
+	return Run(p, env, opts...)
 }
 
 // Compile compiles the input
-func Compile(input string) (*vm.Program, error) {
+func Compile(input string, opts ...Option) (*vm.Program, error) {
+	o := newOptions(opts)
+	if o.err != nil {
+		return nil, o.err
+	}
+
+	if o.maxInputLength > 0 && len(input) > o.maxInputLength {
+		return nil, fmt.Errorf("%w: %d bytes, expected at most %d", ErrInputTooLong, len(input), o.maxInputLength)
+	}
+
 	n, err := parser.New(input).Parse()
 	if err != nil {
-		return nil, err
+		return nil, newError(input, err)
 	}
 
-	return compiler.New().Compile(n)
+	if o.typeCheck {
+		s := o.checkerSchema()
+
+		c := checker.New(s)
+		if o.strict {
+			c = checker.NewStrict(s)
+		}
+
+		rs, err := c.Check(n)
+		if err != nil {
+			return nil, newError(input, err)
+		}
 
+		if o.resultType != "" && !(&types.Spec{Type: o.resultType}).Accepts(rs) {
+			return nil, newError(input, &checker.Error{
+				Message: fmt.Sprintf("invalid result type: %s, expected %s", rs, o.resultType),
+				Start:   n.Pos(),
+				End:     n.End(),
+			})
+		}
+	}
+
+	p, err := compiler.New(o.compilerOptions()...).Compile(n)
+	if err != nil {
+		return nil, newError(input, err)
+	}
+
+	p.Source = input
+	return p, nil
 }
 
 // Run runs the compiled program
-func Run(p *vm.Program, env map[string]any) (any, error) {
-	m, err := types.ToMap(env)
-	if err != nil {
-		return nil, err
+// Environment values are converted when they are first resolved by the program.
+func Run(p *vm.Program, env map[string]any, opts ...Option) (any, error) {
+	return RunContext(context.Background(), p, env, opts...)
+}
+
+// RunContext runs the compiled program, returning an error if the context is cancelled
+func RunContext(ctx context.Context, p *vm.Program, env map[string]any, opts ...Option) (any, error) {
+	o := newOptions(opts)
+
+	convert := types.ToObject
+	if o.reflect {
+		convert = types.ReflectObject
+	}
+
+	r := types.NewLazyMap(env, convert)
+
+	out, err := run(ctx, p, r, o)
+	if cerr := r.Err(); cerr != nil {
+		return nil, cerr
+	}
+
+	return out, err
+}
+
+// RunResolver runs the compiled program, resolving identifiers using the specified resolver
+func RunResolver(p *vm.Program, r types.Resolver, opts ...Option) (any, error) {
+	return run(context.Background(), p, r, newOptions(opts))
+}
+
+func run(ctx context.Context, p *vm.Program, r types.Resolver, o *options) (any, error) {
+	return result(ctx, p, vm.New(p, r, o.vmOpts...), o)
+}
+
+func result(ctx context.Context, p *vm.Program, v *vm.VM, o *options) (any, error) {
+	if o.err != nil {
+		return nil, o.err
 	}
 
-	out, err := vm.New(p, m).Run()
+	out, err := v.RunContext(ctx)
 	if err != nil {
-		return nil, err
+		return nil, newError(p.Source, err)
+	}
+
+	if o.resultType != "" && o.resultType != types.TypeAny {
+		c, ok := types.Convert(out, o.resultType)
+		if !ok {
+			return nil, fmt.Errorf("invalid result type: %s, expected %s", out.Type(), o.resultType)
+		}
+		out = c
 	}
 
 	return types.ToNative(out)
@@ -84,6 +84,7 @@ func benchmarkRun(b *testing.B, bm benchmark) {
 		b.Fatalf("got %v, expected nil", err)
 	}
 
+	b.ReportAllocs()
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
@@ -96,3 +97,35 @@ func benchmarkRun(b *testing.B, bm benchmark) {
 		}
 	}
 }
+
+func BenchmarkRunner_Basic(b *testing.B) {
+	benchmarkRunner(b, basic)
+}
+
+func BenchmarkRunner_Extended(b *testing.B) {
+	benchmarkRunner(b, extended)
+}
+
+func benchmarkRunner(b *testing.B, bm benchmark) {
+	b.Helper()
+
+	p, err := mexl.Compile(bm.expr)
+	if err != nil {
+		b.Fatalf("got %v, expected nil", err)
+	}
+
+	r := mexl.NewRunner(p)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		out, err := r.Run(bm.env)
+		if err != nil {
+			b.Fatalf("got %v, expected nil", err)
+		}
+		if out != true {
+			b.Fatalf("got %v, expected true", out)
+		}
+	}
+}
@@ -1,13 +1,21 @@
 package mexl_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math/big"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stevecallear/mexl"
+	"github.com/stevecallear/mexl/ast/token"
 	"github.com/stevecallear/mexl/types"
+	"github.com/stevecallear/mexl/vm"
 )
 
 func ExampleEval() {
@@ -82,6 +90,98 @@ func TestEval(t *testing.T) {
 			input: "1 lt 2",
 			exp:   true,
 		},
+		{
+			name:  "conditional",
+			input: `tier eq "gold" ? price * 0.9 : if tier eq "silver" then price * 0.95 else price`,
+			env:   map[string]any{"tier": "silver", "price": 100},
+			exp:   float64(95),
+		},
+		{
+			name:  "coalesce",
+			input: `user.tier ?? "free"`,
+			env:   map[string]any{"user": map[string]any{}},
+			exp:   "free",
+		},
+		{
+			name:  "defined",
+			input: `defined(user.tier) and not defined(user.email)`,
+			env:   map[string]any{"user": map[string]any{"tier": nil}},
+			exp:   true,
+		},
+		{
+			name:  "collection predicates",
+			input: `any(orders, o => o.total gt 100) and all(roles, r => r sw "team-")`,
+			env: map[string]any{
+				"orders": []any{map[string]any{"total": 50}, map[string]any{"total": 150}},
+				"roles":  []any{"team-a", "team-b"},
+			},
+			exp: true,
+		},
+		{
+			name:  "collection map",
+			input: `map(filter(orders, o => o.total gt 100), o => o.id)`,
+			env: map[string]any{
+				"orders": []any{map[string]any{"id": "a", "total": 50}, map[string]any{"id": "b", "total": 150}},
+			},
+			exp: []any{"b"},
+		},
+		{
+			name:  "map index",
+			input: `headers["X-Request-Id"] ?? headers[name][0]`,
+			env:   map[string]any{"headers": map[string]any{"Accept": "text/plain"}, "name": "Accept"},
+			exp:   "t",
+		},
+		{
+			name:  "matches",
+			input: `email matches "@corp[.]com$" and regexFind(email, "^[a-z]+") eq "test"`,
+			env:   map[string]any{"email": "test@corp.com"},
+			exp:   true,
+		},
+		{
+			name:  "like",
+			input: `path like "/api/*/users" and email ilike "%@CORP.COM"`,
+			env:   map[string]any{"path": "/api/v1/users", "email": "test@corp.com"},
+			exp:   true,
+		},
+		{
+			name:  "invalid pattern",
+			input: `email =~ "("`,
+			err:   true,
+		},
+		{
+			name:  "time",
+			input: `expiry + ttl`,
+			env: map[string]any{
+				"expiry": time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+				"ttl":    time.Hour,
+			},
+			exp: time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "duration",
+			input: `date("2024-01-02") - date("2024-01-01") ge duration("24h")`,
+			exp:   true,
+		},
+		{
+			name:  "decimal",
+			input: `round(price * 1.2, 2)`,
+			env: map[string]any{
+				"price": big.NewRat(1999, 100),
+			},
+			exp: big.NewRat(2399, 100),
+		},
+		{
+			name:  "slice",
+			input: `tags[-2:]`,
+			env:   map[string]any{"tags": []any{"a", "b", "c"}},
+			exp:   []any{"b", "c"},
+		},
+		{
+			name:  "map literal",
+			input: `{"allow": x gt 1, "reason": "beta"}`,
+			env:   map[string]any{"x": 2},
+			exp:   map[string]any{"allow": true, "reason": "beta"},
+		},
 		{
 			name:  "int",
 			input: "1 + 2",
@@ -138,3 +238,609 @@ func TestEval(t *testing.T) {
 		})
 	}
 }
+
+func TestCompile_WithTypeCheck(t *testing.T) {
+	schema := types.Schema{
+		"user": types.MapOf(map[string]*types.Spec{
+			"email": types.SpecString,
+			"age":   types.SpecInteger,
+		}),
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		err   bool
+	}{
+		{
+			name:  "valid",
+			input: `lower(user.email) ew "@email.com" and user.age ge 18`,
+		},
+		{
+			name:  "invalid operands",
+			input: `user.email - 1`,
+			err:   true,
+		},
+		{
+			name:  "invalid builtin argument",
+			input: `len(user.age)`,
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mexl.Compile(tt.input, mexl.WithTypeCheck(schema))
+			if err != nil && !tt.err {
+				t.Errorf("got %v, expected nil", err)
+			}
+			if err == nil && tt.err {
+				t.Error("got nil, expected error")
+			}
+
+			var e *mexl.Error
+			if err != nil && !errors.As(err, &e) {
+				t.Errorf("got %T, expected mexl.Error", err)
+			}
+		})
+	}
+}
+
+func TestCompile_WithSchema(t *testing.T) {
+	schema := types.Schema{
+		"user": types.MapOf(map[string]*types.Spec{
+			"email": types.SpecString,
+			"roles": types.ArrayOf(types.SpecString),
+		}),
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{
+			name:  "valid",
+			input: `lower(user.email) ew "@email.com" or "beta" in user.roles`,
+		},
+		{
+			name:  "undeclared identifier",
+			input: `usr.email eq "a"`,
+			err:   "1:1: undeclared identifier: usr, did you mean user?",
+		},
+		{
+			name:  "undeclared member",
+			input: `user.emial ew "@email.com"`,
+			err:   "1:6: undeclared member: emial, did you mean email?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mexl.Compile(tt.input, mexl.WithSchema(schema))
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("got %v, expected nil", err)
+				}
+				return
+			}
+
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("got %v, expected %s", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestEval_WithFunction(t *testing.T) {
+	repeat := mexl.WithFunction("repeat", func(args ...types.Object) (types.Object, error) {
+		s := args[0].(*types.String).Value
+		n := args[1].(*types.Integer).Value
+		return &types.String{Value: strings.Repeat(s, int(n))}, nil
+	}, types.FuncOf(types.SpecString, types.SpecString, types.SpecInteger))
+
+	half := mexl.WithFunction("half", func(args ...types.Object) (types.Object, error) {
+		return &types.Float{Value: args[0].(*types.Float).Value / 2}, nil
+	}, types.FuncOf(types.SpecFloat, types.SpecFloat))
+
+	list := mexl.WithFunction("list", func(args ...types.Object) (types.Object, error) {
+		return types.Array(args), nil
+	}, types.FuncOf(types.ArrayOf(types.SpecInteger), types.SpecInteger, types.SpecInteger))
+
+	empty := mexl.WithFunction("empty", func(args ...types.Object) (types.Object, error) {
+		return types.NewInteger(int64(len(args))), nil
+	}, types.VariadicFuncOf(types.SpecInteger))
+
+	tests := []struct {
+		name  string
+		input string
+		env   map[string]any
+		opts  []mexl.Option
+		exp   any
+		err   bool
+	}{
+		{
+			name:  "call",
+			input: `repeat(x, 2)`,
+			env:   map[string]any{"x": "ab"},
+			opts:  []mexl.Option{repeat},
+			exp:   "abab",
+		},
+		{
+			name:  "integer argument to float parameter",
+			input: `half(x)`,
+			env:   map[string]any{"x": 3},
+			opts:  []mexl.Option{half},
+			exp:   1.5,
+		},
+		{
+			name:  "unknown function",
+			input: `reverse("a")`,
+			opts:  []mexl.Option{repeat},
+			err:   true,
+		},
+		{
+			name:  "wrong number of arguments",
+			input: `repeat("a")`,
+			opts:  []mexl.Option{repeat},
+			err:   true,
+		},
+		{
+			name:  "wrong argument type",
+			input: `repeat("a", "b")`,
+			opts:  []mexl.Option{repeat},
+			err:   true,
+		},
+		{
+			name:  "wrong runtime argument type",
+			input: `repeat("a", x)`,
+			env:   map[string]any{"x": "b"},
+			opts:  []mexl.Option{repeat},
+			err:   true,
+		},
+		{
+			name:  "retained args in array literal",
+			input: `[list(1, x), 3, 4]`,
+			env:   map[string]any{"x": 2},
+			opts:  []mexl.Option{list},
+			exp:   []any{[]any{int64(1), int64(2)}, int64(3), int64(4)},
+		},
+		{
+			name:  "retained args in registered built in",
+			input: `[len(1, x), 3, 4]`,
+			env:   map[string]any{"x": 2},
+			opts:  []mexl.Option{mexl.WithFunction("len", func(args ...types.Object) (types.Object, error) { return types.Array(args), nil }, nil)},
+			exp:   []any{[]any{int64(1), int64(2)}, int64(3), int64(4)},
+		},
+		{
+			name:  "retained args in lambda parameter",
+			input: `map(fs, len => [len(1, x), 3, 4])`,
+			env:   map[string]any{"x": 2, "fs": []any{types.Func(func(args ...types.Object) (types.Object, error) { return types.Array(args), nil })}},
+			opts:  []mexl.Option{list},
+			exp:   []any{[]any{[]any{int64(1), int64(2)}, int64(3), int64(4)}},
+		},
+		{
+			name:  "retained args in map literal",
+			input: `{a: list(1, x), b: 7}`,
+			env:   map[string]any{"x": 2},
+			opts:  []mexl.Option{list},
+			exp:   map[string]any{"a": []any{int64(1), int64(2)}, "b": int64(7)},
+		},
+		{
+			name:  "lambda parameter call",
+			input: `any(fs, f => f(1) gt 1)`,
+			env: map[string]any{"fs": []any{
+				types.Func(func(args ...types.Object) (types.Object, error) {
+					return types.NewInteger(args[0].(*types.Integer).Value + 1), nil
+				}),
+			}},
+			opts: []mexl.Option{repeat},
+			exp:  true,
+		},
+		{
+			name:  "variadic without params",
+			input: `empty()`,
+			opts:  []mexl.Option{empty},
+			exp:   int64(0),
+		},
+		{
+			name:  "variadic without params with arguments",
+			input: `empty(1)`,
+			opts:  []mexl.Option{empty},
+			err:   true,
+		},
+		{
+			name:  "variadic without params with type check",
+			input: `empty(1)`,
+			opts:  []mexl.Option{empty, mexl.WithSchema(types.Schema{})},
+			err:   true,
+		},
+		{
+			name:  "schema",
+			input: `repeat("a", 2) eq "aa"`,
+			opts:  []mexl.Option{repeat, mexl.WithSchema(types.Schema{})},
+			exp:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, err := mexl.Eval(tt.input, tt.env, tt.opts...)
+			if err != nil && !tt.err {
+				t.Errorf("got %v, expected nil", err)
+			}
+			if err == nil && tt.err {
+				t.Error("got nil, expected error")
+			}
+			if !reflect.DeepEqual(act, tt.exp) {
+				t.Errorf("got %v, expected %v", act, tt.exp)
+			}
+		})
+	}
+}
+
+func TestEval_Options(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		env   map[string]any
+		opts  []mexl.Option
+		exp   any
+		err   bool
+	}{
+		{
+			name:  "strict null",
+			input: "x + 1",
+			opts:  []mexl.Option{mexl.WithStrictNull()},
+			err:   true,
+		},
+		{
+			name:  "result type conversion",
+			input: "x",
+			opts:  []mexl.Option{mexl.WithResultType(types.TypeBoolean)},
+			exp:   false,
+		},
+		{
+			name:  "result type runtime error",
+			input: "x",
+			env:   map[string]any{"x": "a"},
+			opts:  []mexl.Option{mexl.WithResultType(types.TypeBoolean)},
+			err:   true,
+		},
+		{
+			name:  "result type compile error",
+			input: `"a" + "b"`,
+			opts: []mexl.Option{
+				mexl.WithTypeCheck(nil),
+				mexl.WithResultType(types.TypeBoolean),
+			},
+			err: true,
+		},
+		{
+			name:  "constant folding",
+			input: "x + 2 * 3",
+			env:   map[string]any{"x": 1},
+			opts:  []mexl.Option{mexl.WithConstantFolding(true)},
+			exp:   int64(7),
+		},
+		{
+			name:  "reflection",
+			input: `user.name eq "a" and "admin" in user.roles`,
+			env: map[string]any{
+				"user": &struct {
+					Name  string   `json:"name"`
+					Roles []string `json:"roles"`
+				}{Name: "a", Roles: []string{"admin"}},
+			},
+			opts: []mexl.Option{mexl.WithReflection()},
+			exp:  true,
+		},
+		{
+			name:  "no reflection",
+			input: `"admin" in roles`,
+			env:   map[string]any{"roles": []string{"admin"}},
+			err:   true,
+		},
+		{
+			name:  "lazy conversion",
+			input: `x`,
+			env:   map[string]any{"x": 1, "y": []string{"a"}},
+			exp:   int64(1),
+		},
+		{
+			name:  "max input length",
+			input: "1 + 2",
+			opts:  []mexl.Option{mexl.WithMaxInputLength(3)},
+			err:   true,
+		},
+		{
+			name:  "max stack size",
+			input: "[1, 2, 3]",
+			opts:  []mexl.Option{mexl.WithMaxStackSize(2)},
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, err := mexl.Eval(tt.input, tt.env, tt.opts...)
+			if err != nil && !tt.err {
+				t.Errorf("got %v, expected nil", err)
+			}
+			if err == nil && tt.err {
+				t.Error("got nil, expected error")
+			}
+			if !reflect.DeepEqual(act, tt.exp) {
+				t.Errorf("got %v, expected %v", act, tt.exp)
+			}
+		})
+	}
+}
+
+func TestEval_ConstantFolding(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  []mexl.Option
+	}{
+		{
+			name:  "strict null",
+			input: "null + 1",
+			opts:  []mexl.Option{mexl.WithStrictNull()},
+		},
+		{
+			name:  "max steps",
+			input: "[1, 2, 3, 4, 5, 6, 7, 8, 9, 10][0]",
+			opts:  []mexl.Option{mexl.WithMaxSteps(3)},
+		},
+		{
+			name:  "max steps partial",
+			input: "x + (1 + 2) + (3 + 4)",
+			opts:  []mexl.Option{mexl.WithMaxSteps(6)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp, experr := mexl.Eval(tt.input, map[string]any{"x": 1}, tt.opts...)
+			if experr == nil {
+				t.Fatal("got nil, expected error")
+			}
+
+			act, err := mexl.Eval(tt.input, map[string]any{"x": 1}, append(tt.opts, mexl.WithConstantFolding(true))...)
+			if err == nil || err.Error() != experr.Error() {
+				t.Errorf("got %v, expected %v", err, experr)
+			}
+			if !reflect.DeepEqual(act, exp) {
+				t.Errorf("got %v, expected %v", act, exp)
+			}
+		})
+	}
+}
+
+func TestRunResolver(t *testing.T) {
+	p, err := mexl.Compile(`user.name eq "a"`)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	r := types.Map{
+		"user": types.Map{"name": &types.String{Value: "a"}},
+	}
+
+	act, err := mexl.RunResolver(p, r)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if act != true {
+		t.Errorf("got %v, expected true", act)
+	}
+}
+
+func TestRunner(t *testing.T) {
+	p, err := mexl.Compile(`x + 1`)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	sut := mexl.NewRunner(p, mexl.WithResultType(types.TypeFloat))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			act, err := sut.Run(map[string]any{"x": i})
+			if err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+			if exp := float64(i + 1); act != exp {
+				t.Errorf("got %v, expected %v", act, exp)
+			}
+		}()
+	}
+	wg.Wait()
+
+	act, err := sut.RunResolver(types.Map{"x": &types.Integer{Value: 1}})
+	if err != nil {
+		t.Errorf("got %v, expected nil", err)
+	}
+	if act != float64(2) {
+		t.Errorf("got %v, expected 2", act)
+	}
+
+	if _, err = sut.Run(map[string]any{"x": []string{}}); err == nil {
+		t.Error("got nil, expected error")
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	p, err := mexl.Compile(`wait(x)`, mexl.WithContextFunction("wait", func(ctx context.Context, args ...types.Object) (types.Object, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, types.FuncOf(types.SpecBoolean, types.SpecInteger)))
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = mexl.RunContext(ctx, p, map[string]any{"x": 1})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, expected %v", err, context.DeadlineExceeded)
+	}
+
+	_, err = mexl.NewRunner(p).RunContext(ctx, map[string]any{"x": 1})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, expected %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRun_WithMaxSteps(t *testing.T) {
+	p, err := mexl.Compile(`x + 1 gt 2`)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	_, err = mexl.Run(p, map[string]any{"x": 1}, mexl.WithMaxSteps(5))
+	if err != nil {
+		t.Errorf("got %v, expected nil", err)
+	}
+
+	_, err = mexl.Run(p, map[string]any{"x": 1}, mexl.WithMaxSteps(4))
+	if !errors.Is(err, vm.ErrStepLimit) {
+		t.Errorf("got %v, expected %v", err, vm.ErrStepLimit)
+	}
+}
+
+func TestEval_Limits(t *testing.T) {
+	_, err := mexl.Eval(`1 + 2`, nil, mexl.WithMaxInputLength(3))
+	if !errors.Is(err, mexl.ErrInputTooLong) {
+		t.Errorf("got %v, expected %v", err, mexl.ErrInputTooLong)
+	}
+
+	_, err = mexl.Eval(`1 + 2`, nil, mexl.WithMaxStackSize(-1))
+	if !errors.Is(err, vm.ErrInvalidStackSize) {
+		t.Errorf("got %v, expected %v", err, vm.ErrInvalidStackSize)
+	}
+
+	p, err := mexl.Compile(`1 + 2`)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	_, err = mexl.NewRunner(p, mexl.WithMaxStackSize(-1)).Run(nil)
+	if !errors.Is(err, vm.ErrInvalidStackSize) {
+		t.Errorf("got %v, expected %v", err, vm.ErrInvalidStackSize)
+	}
+}
+
+func TestEval_WithResultType(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		typ   types.Type
+		exp   any
+		err   bool
+	}{
+		{name: "null", input: "null", typ: types.TypeBoolean, exp: false},
+		{name: "unknown type", input: "null", typ: "bool", err: true},
+		{name: "func type", input: "null", typ: types.TypeFunc, err: true},
+		{name: "null type", input: "1", typ: types.TypeNull, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, err := mexl.Eval(tt.input, nil, mexl.WithResultType(tt.typ))
+			if err != nil && !tt.err {
+				t.Errorf("got %v, expected nil", err)
+			}
+			if err == nil && tt.err {
+				t.Error("got nil, expected error")
+			}
+			if !reflect.DeepEqual(act, tt.exp) {
+				t.Errorf("got %v, expected %v", act, tt.exp)
+			}
+
+			p, err := mexl.Compile(tt.input)
+			if err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+
+			_, err = mexl.NewRunner(p, mexl.WithResultType(tt.typ)).Run(nil)
+			if err == nil && tt.err {
+				t.Error("got nil, expected error")
+			}
+		})
+	}
+}
+
+func TestEval_Panics(t *testing.T) {
+	_, err := mexl.Eval(`x % 0`, map[string]any{"x": 1})
+	if !errors.Is(err, vm.ErrDivisionByZero) {
+		t.Errorf("got %v, expected %v", err, vm.ErrDivisionByZero)
+	}
+
+	index := mexl.WithFunction("index", func(args ...types.Object) (types.Object, error) {
+		return args[0].(types.Array)[5], nil
+	}, nil)
+
+	_, err = mexl.Eval(`index([1])`, nil, index)
+	if !errors.Is(err, vm.ErrFunctionPanic) {
+		t.Errorf("got %v, expected %v", err, vm.ErrFunctionPanic)
+	}
+}
+
+func TestError(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		start   token.Position
+		end     token.Position
+		snippet string
+	}{
+		{
+			name:    "parse error",
+			input:   "x y",
+			start:   token.Position{Offset: 2, Line: 1, Column: 3},
+			end:     token.Position{Offset: 3, Line: 1, Column: 4},
+			snippet: "1 | x y\n  |   ^",
+		},
+		{
+			name:    "compile error",
+			input:   `x."a"`,
+			start:   token.Position{Offset: 2, Line: 1, Column: 3},
+			end:     token.Position{Offset: 5, Line: 1, Column: 6},
+			snippet: "1 | x.\"a\"\n  |   ^^^",
+		},
+		{
+			name:    "runtime error",
+			input:   "x eq 1 or\n\t\"a\" - 1",
+			start:   token.Position{Offset: 11, Line: 2, Column: 2},
+			end:     token.Position{Offset: 18, Line: 2, Column: 9},
+			snippet: "2 | \t\"a\" - 1\n  | \t^^^^^^^",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mexl.Eval(tt.input, nil)
+
+			var act *mexl.Error
+			if !errors.As(err, &act) {
+				t.Fatalf("got %v, expected mexl.Error", err)
+			}
+			if act.Start != tt.start {
+				t.Errorf("got %v, expected %v", act.Start, tt.start)
+			}
+			if act.End != tt.end {
+				t.Errorf("got %v, expected %v", act.End, tt.end)
+			}
+			if s := act.Snippet(); s != tt.snippet {
+				t.Errorf("got %q, expected %q", s, tt.snippet)
+			}
+		})
+	}
+}
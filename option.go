@@ -0,0 +1,195 @@
+package mexl
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/stevecallear/mexl/compiler"
+	"github.com/stevecallear/mexl/types"
+	"github.com/stevecallear/mexl/vm"
+)
+
+type (
+	// Option represents a compile or run option
+	// Options that do not apply to the current operation are ignored
+	Option func(*options)
+
+	options struct {
+		typeCheck      bool
+		strict         bool
+		schema         types.Schema
+		functions      types.Schema
+		resultType     types.Type
+		maxInputLength int
+		maxSteps       int
+		fold           bool
+		reflect        bool
+		compilerOpts   []compiler.Option
+		vmOpts         []vm.Option
+		err            error
+	}
+)
+
+// resultTypes are the types that can be specified using WithResultType
+var resultTypes = map[types.Type]bool{
+	types.TypeAny:      true,
+	types.TypeInteger:  true,
+	types.TypeFloat:    true,
+	types.TypeDecimal:  true,
+	types.TypeString:   true,
+	types.TypeBoolean:  true,
+	types.TypeArray:    true,
+	types.TypeMap:      true,
+	types.TypeTime:     true,
+	types.TypeDuration: true,
+}
+
+// WithTypeCheck type checks the expression against the schema prior to compilation
+// Identifiers that are not declared in the schema are treated as dynamically typed
+func WithTypeCheck(s types.Schema) Option {
+	return func(o *options) {
+		o.typeCheck = true
+		o.schema = s
+	}
+}
+
+// WithSchema declares the identifiers and members available to the expression
+// The expression is type checked and any undeclared identifiers or members are reported as compile errors
+func WithSchema(s types.Schema) Option {
+	return func(o *options) {
+		o.typeCheck = true
+		o.strict = true
+		o.schema = s
+	}
+}
+
+// WithFunction registers a function that is resolved at compile time
+// If a signature is specified, call arity and argument types are validated against it.
+// Once a function is registered, calls to unknown functions are reported as compile errors.
+func WithFunction(name string, fn types.Func, sig *types.Spec) Option {
+	return func(o *options) {
+		if o.functions == nil {
+			o.functions = types.Schema{}
+		}
+		o.compilerOpts = append(o.compilerOpts, compiler.WithFunction(name, fn, sig))
+
+		if sig == nil {
+			sig = &types.Spec{Type: types.TypeFunc}
+		}
+		o.functions[name] = sig
+	}
+}
+
+// WithContextFunction registers a function that receives the context of the current run
+// See WithFunction for details of signature validation.
+func WithContextFunction(name string, fn types.ContextFunc, sig *types.Spec) Option {
+	return func(o *options) {
+		if o.functions == nil {
+			o.functions = types.Schema{}
+		}
+
+		o.compilerOpts = append(o.compilerOpts, compiler.WithContextFunction(name, fn, sig))
+
+		if sig == nil {
+			sig = &types.Spec{Type: types.TypeFunc}
+		}
+		o.functions[name] = sig
+	}
+}
+
+// WithResultType specifies the expected result type
+// If the expression is type checked, an incompatible result type is reported as a compile error.
+// At runtime the result is converted to the expected type, returning an error if conversion is not possible.
+// An unsupported type, such as types.TypeFunc, causes Compile and Run to return an error.
+func WithResultType(t types.Type) Option {
+	return func(o *options) {
+		if !resultTypes[t] {
+			o.err = fmt.Errorf("invalid result type: %s", t)
+			return
+		}
+		o.resultType = t
+	}
+}
+
+// WithConstantFolding evaluates constant expressions at compile time if enabled
+// Expressions are evaluated using the run options specified at compile time, such as WithStrictNull.
+// Folding is disabled if WithMaxSteps is specified, as it changes the number of instructions executed.
+func WithConstantFolding(enabled bool) Option {
+	return func(o *options) {
+		o.fold = enabled
+	}
+}
+
+// WithStrictNull disables null coalescing, causing operations on null values to return an error at runtime
+// Null values can still be compared using the eq and ne operators.
+func WithStrictNull() Option {
+	return func(o *options) {
+		o.vmOpts = append(o.vmOpts, vm.WithStrictNull())
+	}
+}
+
+// WithReflection converts environment values using reflection
+// This allows structs, pointers, typed slices and typed maps to be used in the environment, at the cost of performance.
+func WithReflection() Option {
+	return func(o *options) {
+		o.reflect = true
+	}
+}
+
+// WithMaxInputLength limits the length of the input expression in bytes
+// If the limit is exceeded, ErrInputTooLong is returned.
+func WithMaxInputLength(n int) Option {
+	return func(o *options) {
+		o.maxInputLength = n
+	}
+}
+
+// WithMaxStackSize limits the size of the runtime stack
+// A negative size causes Run to return vm.ErrInvalidStackSize.
+func WithMaxStackSize(n int) Option {
+	return func(o *options) {
+		o.vmOpts = append(o.vmOpts, vm.WithStackSize(n))
+	}
+}
+
+// WithMaxSteps limits the number of instructions executed by a single run
+// If the limit is exceeded, vm.ErrStepLimit is returned.
+func WithMaxSteps(n int) Option {
+	return func(o *options) {
+		o.maxSteps = n
+		o.vmOpts = append(o.vmOpts, vm.WithMaxSteps(n))
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := new(options)
+	for _, fn := range opts {
+		fn(o)
+	}
+	return o
+}
+
+// compilerOptions returns the compiler options, including constant folding if enabled
+func (o *options) compilerOptions() []compiler.Option {
+	if !o.fold || o.maxSteps > 0 {
+		return o.compilerOpts
+	}
+
+	return append(slices.Clip(o.compilerOpts), compiler.WithConstantFolding(o.vmOpts...))
+}
+
+// checkerSchema returns the schema including the signatures of registered functions
+func (o *options) checkerSchema() types.Schema {
+	if len(o.functions) < 1 {
+		return o.schema
+	}
+
+	s := make(types.Schema, len(o.schema)+len(o.functions))
+	for k, v := range o.schema {
+		s[k] = v
+	}
+	for k, v := range o.functions {
+		s[k] = v
+	}
+	return s
+}
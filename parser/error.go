@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/stevecallear/mexl/ast/token"
+)
+
+type (
+	// Code identifies the kind of parse error
+	Code uint8
+
+	// Error represents a parse diagnostic and the source span that caused it
+	Error struct {
+		Code     Code
+		Message  string
+		Found    string
+		Expected []token.Type
+		Start    token.Position
+		End      token.Position
+	}
+
+	// ErrorList represents the diagnostics reported by a single parse
+	ErrorList []*Error
+)
+
+const (
+	CodeUnexpectedToken Code = iota + 1
+	CodeMissingExpression
+	CodeIllegalToken
+	CodeInvalidLiteral
+)
+
+var codes = map[Code]string{
+	CodeUnexpectedToken:   "unexpected token",
+	CodeMissingExpression: "missing expression",
+	CodeIllegalToken:      "illegal token",
+	CodeInvalidLiteral:    "invalid literal",
+}
+
+// Error returns the code description, allowing codes to be used as errors.Is targets
+func (c Code) Error() string {
+	if s, ok := codes[c]; ok {
+		return s
+	}
+	return "code(" + strconv.Itoa(int(c)) + ")"
+}
+
+// Error returns the error message prefixed with the start position
+func (e *Error) Error() string {
+	if !e.Start.IsValid() {
+		return e.Message
+	}
+	return e.Start.String() + ": " + e.Message
+}
+
+// Unwrap returns the error code
+func (e *Error) Unwrap() error {
+	return e.Code
+}
+
+// Error returns the newline separated error messages
+func (l ErrorList) Error() string {
+	es := make([]string, len(l))
+	for i, e := range l {
+		es[i] = e.Error()
+	}
+	return strings.Join(es, "\n")
+}
+
+// Unwrap returns the errors in the list
+func (l ErrorList) Unwrap() []error {
+	es := make([]error, len(l))
+	for i, e := range l {
+		es[i] = e
+	}
+	return es
+}
+
+func describe(t token.Token) string {
+	switch t.Type {
+	case token.Ident, token.Int, token.Float:
+		return t.Type.String() + " " + t.Literal
+	case token.String:
+		return t.Type.String() + " " + strconv.Quote(t.Literal)
+	default:
+		return t.Type.String()
+	}
+}
+
+func describeAll(ts []token.Type) string {
+	ns := make([]string, len(ts))
+	for i, t := range ts {
+		ns[i] = t.String()
+	}
+
+	if len(ns) < 2 {
+		return strings.Join(ns, "")
+	}
+	return strings.Join(ns[:len(ns)-1], ", ") + " or " + ns[len(ns)-1]
+}
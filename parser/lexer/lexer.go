@@ -5,32 +5,40 @@ import (
 )
 
 type Lexer struct {
-	input   string
-	pos     int
-	readPos int
-	ch      byte
+	input     string
+	pos       int
+	readPos   int
+	ch        byte
+	line      int
+	lineStart int
 }
 
 var keywords = map[string]token.Type{
-	"true":  token.True,
-	"false": token.False,
-	"and":   token.And,
-	"or":    token.Or,
-	"not":   token.Bang,
-	"eq":    token.Equal,
-	"ne":    token.NotEqual,
-	"lt":    token.LessThan,
-	"gt":    token.GreaterThan,
-	"le":    token.LessThanOrEqual,
-	"ge":    token.GreaterThanOrEqual,
-	"sw":    token.StartsWith,
-	"ew":    token.EndsWith,
-	"in":    token.In,
-	"null":  token.Null,
+	"true":    token.True,
+	"false":   token.False,
+	"and":     token.And,
+	"or":      token.Or,
+	"not":     token.Bang,
+	"eq":      token.Equal,
+	"ne":      token.NotEqual,
+	"lt":      token.LessThan,
+	"gt":      token.GreaterThan,
+	"le":      token.LessThanOrEqual,
+	"ge":      token.GreaterThanOrEqual,
+	"sw":      token.StartsWith,
+	"ew":      token.EndsWith,
+	"in":      token.In,
+	"matches": token.Matches,
+	"like":    token.Like,
+	"ilike":   token.ILike,
+	"null":    token.Null,
+	"if":      token.If,
+	"then":    token.Then,
+	"else":    token.Else,
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	l := &Lexer{input: input, line: 1}
 	l.readChar()
 	return l
 }
@@ -41,6 +49,7 @@ func (l *Lexer) NextToken() (t token.Token) {
 	}
 
 	l.skipWhitespace()
+	start := l.position()
 
 	var skipRead bool // todo: it should be possible to remove this
 
@@ -88,6 +97,25 @@ func (l *Lexer) NextToken() (t token.Token) {
 	case ']':
 		t = newToken(token.RBracket, l.ch)
 
+	case '{':
+		t = newToken(token.LBrace, l.ch)
+
+	case '}':
+		t = newToken(token.RBrace, l.ch)
+
+	case ':':
+		t = newToken(token.Colon, l.ch)
+
+	case '?':
+		switch l.peekChar() {
+		case '?':
+			ch := l.ch
+			l.readChar()
+			t = newToken(token.Coalesce, ch, l.ch)
+		default:
+			t = newToken(token.Question, l.ch)
+		}
+
 	case '"':
 		t = l.readString()
 
@@ -98,6 +126,10 @@ func (l *Lexer) NextToken() (t token.Token) {
 		switch l.ch {
 		case '=':
 			t = newToken(token.Equal, ch, l.ch)
+		case '>':
+			t = newToken(token.Arrow, ch, l.ch)
+		case '~':
+			t = newToken(token.Matches, ch, l.ch)
 		default:
 			t = newToken(token.Illegal, ch, l.ch)
 		}
@@ -176,10 +208,17 @@ func (l *Lexer) NextToken() (t token.Token) {
 		l.readChar()
 	}
 
+	t.Start = start
+	t.End = l.position()
 	return t
 }
 
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.lineStart = l.readPos
+	}
+
 	if l.readPos >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -190,6 +229,16 @@ func (l *Lexer) readChar() {
 	l.readPos += 1
 }
 
+func (l *Lexer) position() token.Position {
+	pos := min(l.pos, len(l.input))
+
+	return token.Position{
+		Offset: pos,
+		Line:   l.line,
+		Column: pos - l.lineStart + 1,
+	}
+}
+
 func (l *Lexer) peekChar() byte {
 	if l.readPos >= len(l.input) {
 		return 0
@@ -225,6 +274,12 @@ func (l *Lexer) readNumber() token.Token {
 		l.readChar()
 	}
 
+	// a d suffix denotes a decimal literal, for example 1.10d
+	if l.ch == 'd' && !isLetter(l.peekChar()) {
+		t.Type = token.Decimal
+		l.readChar()
+	}
+
 	t.Literal = l.input[p:l.pos]
 	return t
 }
@@ -16,7 +16,7 @@ func TestLexer_NextToken(t *testing.T) {
 	}{
 		{
 			name:  "operators",
-			input: "+ - * / % not and or eq ne lt gt le ge sw ew in ! && || == != < > <= >= !",
+			input: "+ - * / % not and or eq ne lt gt le ge sw ew in matches like ilike ! && || == != < > <= >= =~ !",
 			exp: []token.Token{
 				{Type: token.Plus, Literal: "+"},
 				{Type: token.Minus, Literal: "-"},
@@ -35,6 +35,9 @@ func TestLexer_NextToken(t *testing.T) {
 				{Type: token.StartsWith, Literal: "sw"},
 				{Type: token.EndsWith, Literal: "ew"},
 				{Type: token.In, Literal: "in"},
+				{Type: token.Matches, Literal: "matches"},
+				{Type: token.Like, Literal: "like"},
+				{Type: token.ILike, Literal: "ilike"},
 				{Type: token.Bang, Literal: "!"},
 				{Type: token.And, Literal: "&&"},
 				{Type: token.Or, Literal: "||"},
@@ -44,12 +47,13 @@ func TestLexer_NextToken(t *testing.T) {
 				{Type: token.GreaterThan, Literal: ">"},
 				{Type: token.LessThanOrEqual, Literal: "<="},
 				{Type: token.GreaterThanOrEqual, Literal: ">="},
+				{Type: token.Matches, Literal: "=~"},
 				{Type: token.Bang, Literal: "!"}, // test EOF peek
 			},
 		},
 		{
 			name:  "delimiters",
-			input: ".,()[]",
+			input: ".,()[]{}:",
 			exp: []token.Token{
 				{Type: token.Stop, Literal: "."},
 				{Type: token.Comma, Literal: ","},
@@ -57,6 +61,9 @@ func TestLexer_NextToken(t *testing.T) {
 				{Type: token.RParen, Literal: ")"},
 				{Type: token.LBracket, Literal: "["},
 				{Type: token.RBracket, Literal: "]"},
+				{Type: token.LBrace, Literal: "{"},
+				{Type: token.RBrace, Literal: "}"},
+				{Type: token.Colon, Literal: ":"},
 			},
 		},
 		{
@@ -97,6 +104,19 @@ func TestLexer_NextToken(t *testing.T) {
 				{Type: token.RParen, Literal: ")"},
 			},
 		},
+		{
+			name:  "decimals",
+			input: "1d 0.10d .5d -1.5d 1dx",
+			exp: []token.Token{
+				{Type: token.Decimal, Literal: "1d"},
+				{Type: token.Decimal, Literal: "0.10d"},
+				{Type: token.Decimal, Literal: ".5d"},
+				{Type: token.Minus, Literal: "-"},
+				{Type: token.Decimal, Literal: "1.5d"},
+				{Type: token.Int, Literal: "1"},
+				{Type: token.Ident, Literal: "dx"},
+			},
+		},
 		{
 			name:  "idents",
 			input: "abc ABC x.y",
@@ -116,6 +136,18 @@ func TestLexer_NextToken(t *testing.T) {
 				{Type: token.False, Literal: "false"},
 			},
 		},
+		{
+			name:  "conditionals",
+			input: "if then else ? ?? =>",
+			exp: []token.Token{
+				{Type: token.If, Literal: "if"},
+				{Type: token.Then, Literal: "then"},
+				{Type: token.Else, Literal: "else"},
+				{Type: token.Question, Literal: "?"},
+				{Type: token.Coalesce, Literal: "??"},
+				{Type: token.Arrow, Literal: "=>"},
+			},
+		},
 		{
 			name:  "null",
 			input: "null",
@@ -165,7 +197,7 @@ func TestLexer_NextToken(t *testing.T) {
 			var act []token.Token
 
 			for tok := sut.NextToken(); tok.Type != token.EOF; tok = sut.NextToken() {
-				act = append(act, tok)
+				act = append(act, token.Token{Type: tok.Type, Literal: tok.Literal})
 			}
 
 			if !reflect.DeepEqual(act, tt.exp) {
@@ -174,3 +206,53 @@ func TestLexer_NextToken(t *testing.T) {
 		})
 	}
 }
+
+func TestLexer_NextToken_Position(t *testing.T) {
+	const input = "x eq\n  \"abc\" and\r\n1.5"
+
+	exp := []token.Token{
+		{
+			Type:    token.Ident,
+			Literal: "x",
+			Start:   token.Position{Offset: 0, Line: 1, Column: 1},
+			End:     token.Position{Offset: 1, Line: 1, Column: 2},
+		},
+		{
+			Type:    token.Equal,
+			Literal: "eq",
+			Start:   token.Position{Offset: 2, Line: 1, Column: 3},
+			End:     token.Position{Offset: 4, Line: 1, Column: 5},
+		},
+		{
+			Type:    token.String,
+			Literal: "abc",
+			Start:   token.Position{Offset: 7, Line: 2, Column: 3},
+			End:     token.Position{Offset: 12, Line: 2, Column: 8},
+		},
+		{
+			Type:    token.And,
+			Literal: "and",
+			Start:   token.Position{Offset: 13, Line: 2, Column: 9},
+			End:     token.Position{Offset: 16, Line: 2, Column: 12},
+		},
+		{
+			Type:    token.Float,
+			Literal: "1.5",
+			Start:   token.Position{Offset: 18, Line: 3, Column: 1},
+			End:     token.Position{Offset: 21, Line: 3, Column: 4},
+		},
+		{
+			Type:    token.EOF,
+			Literal: "EOF",
+			Start:   token.Position{Offset: 21, Line: 3, Column: 4},
+			End:     token.Position{Offset: 21, Line: 3, Column: 4},
+		},
+	}
+
+	sut := lexer.New(input)
+	for _, e := range exp {
+		if act := sut.NextToken(); !reflect.DeepEqual(act, e) {
+			t.Errorf("got %+v, expected %+v", act, e)
+		}
+	}
+}
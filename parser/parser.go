@@ -1,7 +1,6 @@
 package parser
 
 import (
-	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -9,6 +8,7 @@ import (
 	"github.com/stevecallear/mexl/ast"
 	"github.com/stevecallear/mexl/ast/token"
 	"github.com/stevecallear/mexl/parser/lexer"
+	"github.com/stevecallear/mexl/types"
 )
 
 type (
@@ -20,7 +20,8 @@ type (
 		l            Lexer
 		currentToken token.Token
 		peekToken    token.Token
-		errors       []string
+		errors       ErrorList
+		panicking    bool
 	}
 
 	prefixParseFn func() ast.Node
@@ -29,10 +30,13 @@ type (
 
 const (
 	precedenceLowest int = iota + 1
+	precedenceLambda
+	precedenceConditional
 	precedenceOr
 	precedenceAnd
 	precedenceEquals
 	precedenceLessGreater
+	precedenceCoalesce
 	precedenceSum
 	precedenceProduct
 	precedencePrefix
@@ -44,6 +48,8 @@ const (
 )
 
 var precedences = map[token.Type]int{
+	token.Arrow:              precedenceLambda,
+	token.Question:           precedenceConditional,
 	token.Or:                 precedenceOr,
 	token.And:                precedenceAnd,
 	token.Equal:              precedenceEquals,
@@ -54,7 +60,11 @@ var precedences = map[token.Type]int{
 	token.GreaterThanOrEqual: precedenceLessGreater,
 	token.StartsWith:         precedenceStartsEndsWith,
 	token.EndsWith:           precedenceStartsEndsWith,
+	token.Matches:            precedenceStartsEndsWith,
+	token.Like:               precedenceStartsEndsWith,
+	token.ILike:              precedenceStartsEndsWith,
 	token.In:                 precedenceIn,
+	token.Coalesce:           precedenceCoalesce,
 	token.Plus:               precedenceSum,
 	token.Minus:              precedenceSum,
 	token.Asterisk:           precedenceProduct,
@@ -77,19 +87,21 @@ func NewWithLexer(l Lexer) *Parser {
 	return p
 }
 
+// Parse parses the input, returning an ErrorList if any errors are encountered
 func (p *Parser) Parse() (ast.Node, error) {
 	n := p.parseExpression(precedenceLowest)
 
-	if p.peekToken.Type != token.EOF {
-		p.error(fmt.Sprintf("multiple expressions found, next: %d", p.peekToken.Type))
+	for !p.peekTokenIs(token.EOF) {
+		p.unexpected(p.peekToken, token.EOF)
+
+		// continue parsing the remaining input to report any further errors
+		p.panicking = false
+		p.nextToken()
+		p.parseExpression(precedenceLowest)
 	}
 
 	if len(p.errors) > 0 {
-		var b strings.Builder
-		for _, err := range p.errors {
-			b.WriteString(err + "\n")
-		}
-		return nil, errors.New(b.String())
+		return nil, p.errors
 	}
 
 	return n, nil
@@ -98,13 +110,18 @@ func (p *Parser) Parse() (ast.Node, error) {
 func (p *Parser) parseExpression(precedence int) ast.Node {
 	fn, ok := p.getPrefixParseFn(p.currentToken.Type)
 	if !ok {
-		p.error(fmt.Sprintf("no prefix parse function: %s", p.currentToken.Literal))
+		if p.curTokenIs(token.Illegal) {
+			p.illegal(p.currentToken)
+		} else {
+			p.error(CodeMissingExpression, p.currentToken, nil, "expected expression, found %s", describe(p.currentToken))
+		}
 		return nil
 	}
 
 	e := fn()
 
-	for !p.peekTokenIs(token.EOF) && precedence < p.peekPrecedence() {
+	// once an error is reported the caller synchronizes, so operators must not be consumed
+	for !p.panicking && !p.peekTokenIs(token.EOF) && precedence < p.peekPrecedence() {
 		fn, ok := p.getInfixParseFn(p.peekToken.Type)
 		if !ok {
 			return e
@@ -125,6 +142,8 @@ func (p *Parser) getPrefixParseFn(t token.Type) (prefixParseFn, bool) {
 		return p.parseIntegerLiteral, true
 	case token.Float:
 		return p.parseFloatLiteral, true
+	case token.Decimal:
+		return p.parseDecimalLiteral, true
 	case token.String:
 		return p.parseStringLiteral, true
 	case token.Bang, token.Minus:
@@ -137,6 +156,10 @@ func (p *Parser) getPrefixParseFn(t token.Type) (prefixParseFn, bool) {
 		return p.parseGroupedExpression, true
 	case token.LBracket:
 		return p.parseArrayLiteral, true
+	case token.LBrace:
+		return p.parseMapLiteral, true
+	case token.If:
+		return p.parseIfExpression, true
 	default:
 		return nil, false
 	}
@@ -150,7 +173,7 @@ func (p *Parser) getInfixParseFn(t token.Type) (infixParseFn, bool) {
 		return p.parseInfixExpression, true
 	case token.And, token.Or:
 		return p.parseInfixExpression, true
-	case token.StartsWith, token.EndsWith, token.In:
+	case token.StartsWith, token.EndsWith, token.Matches, token.Like, token.ILike, token.In, token.Coalesce:
 		return p.parseInfixExpression, true
 	case token.LParen:
 		return p.parseCallExpression, true
@@ -158,6 +181,10 @@ func (p *Parser) getInfixParseFn(t token.Type) (infixParseFn, bool) {
 		return p.parseIndexExpression, true
 	case token.Stop:
 		return p.parseMemberExpression, true
+	case token.Question:
+		return p.parseConditionalExpression, true
+	case token.Arrow:
+		return p.parseLambda, true
 	default:
 		return nil, false
 	}
@@ -198,12 +225,79 @@ func (p *Parser) parseMemberExpression(left ast.Node) ast.Node {
 	return e
 }
 
+func (p *Parser) parseLambda(param ast.Node) ast.Node {
+	ident, ok := param.(*ast.Identifier)
+	if !ok {
+		p.error(CodeUnexpectedToken, p.currentToken, nil, "invalid lambda parameter: %s", param)
+		return nil
+	}
+
+	e := &ast.Lambda{Token: p.currentToken, Parameter: ident}
+	p.nextToken()
+
+	e.Body = p.parseExpression(precedenceLowest)
+	if p.panicking {
+		return nil
+	}
+
+	return e
+}
+
+// parseConditionalExpression parses a ternary conditional
+// The alternative is parsed with the lowest precedence so that nested conditionals are right associative
+func (p *Parser) parseConditionalExpression(cond ast.Node) ast.Node {
+	e := &ast.ConditionalExpression{Token: p.currentToken, Condition: cond}
+	p.nextToken()
+
+	e.Consequence = p.parseExpression(precedenceLowest)
+	if p.panicking || !p.expectPeek(token.Colon) {
+		return nil
+	}
+
+	p.nextToken()
+	e.Alternative = p.parseExpression(precedenceLowest)
+	if p.panicking {
+		return nil
+	}
+
+	return e
+}
+
+func (p *Parser) parseIfExpression() ast.Node {
+	e := &ast.ConditionalExpression{Token: p.currentToken}
+	p.nextToken()
+
+	e.Condition = p.parseExpression(precedenceLowest)
+	if p.panicking || !p.expectPeek(token.Then) {
+		return nil
+	}
+
+	p.nextToken()
+	e.Consequence = p.parseExpression(precedenceLowest)
+	if p.panicking || !p.expectPeek(token.Else) {
+		return nil
+	}
+
+	p.nextToken()
+	e.Alternative = p.parseExpression(precedenceLowest)
+	if p.panicking {
+		return nil
+	}
+
+	return e
+}
+
 func (p *Parser) parseGroupedExpression() ast.Node {
 	p.nextToken()
 
 	e := p.parseExpression(precedenceLowest)
 
-	if !p.expectPeek(token.RParen) {
+	if !p.panicking && !p.expectPeek(token.RParen) {
+		p.nextToken()
+	}
+
+	if p.panicking {
+		p.skipTo(token.RParen)
 		return nil
 	}
 
@@ -211,11 +305,11 @@ func (p *Parser) parseGroupedExpression() ast.Node {
 }
 
 func (p *Parser) parseCallExpression(fn ast.Node) ast.Node {
-	return &ast.CallExpression{
-		Token:     p.currentToken,
-		Function:  fn,
-		Arguments: p.parseExpressionList(token.RParen),
-	}
+	e := &ast.CallExpression{Token: p.currentToken, Function: fn}
+
+	e.Arguments = p.parseExpressionList(token.RParen)
+	e.Close = p.currentToken
+	return e
 }
 
 func (p *Parser) parseExpressionList(t token.Type) []ast.Node {
@@ -226,31 +320,115 @@ func (p *Parser) parseExpressionList(t token.Type) []ast.Node {
 		return l
 	}
 
-	p.nextToken()
-	l = append(l, p.parseExpression(precedenceLowest))
-
-	for p.peekTokenIs(token.Comma) {
-		p.nextToken()
+	for {
 		p.nextToken()
 		l = append(l, p.parseExpression(precedenceLowest))
+
+		if !p.panicking {
+			if p.peekTokenIs(token.Comma) {
+				p.nextToken()
+				continue
+			}
+			if p.expectPeek(t, token.Comma) {
+				return l
+			}
+			p.nextToken()
+		}
+
+		// skip the invalid element and resume from the next delimiter
+		switch p.synchronize(t) {
+		case token.Comma:
+			continue
+		case t:
+			return l
+		default:
+			return nil
+		}
 	}
+}
 
-	if !p.expectPeek(t) {
-		return nil
+// synchronize advances to the next comma or closing token at the current nesting level
+// and clears the panicking state, allowing subsequent errors to be reported
+func (p *Parser) synchronize(t token.Type) token.Type {
+	p.panicking = false
+
+	depth := 0
+	for !p.curTokenIs(token.EOF) {
+		switch p.currentToken.Type {
+		case token.LParen, token.LBracket, token.LBrace:
+			depth++
+
+		case token.RParen, token.RBracket, token.RBrace:
+			if depth > 0 {
+				depth--
+			} else if p.curTokenIs(t) {
+				return t
+			}
+
+		case token.Comma:
+			if depth == 0 {
+				return token.Comma
+			}
+		}
+
+		p.nextToken()
 	}
 
-	return l
+	return token.EOF
+}
+
+// skipTo synchronizes to the closing token t, skipping any commas
+func (p *Parser) skipTo(t token.Type) {
+	for p.synchronize(t) == token.Comma {
+		p.nextToken()
+	}
 }
 
 func (p *Parser) parseIndexExpression(left ast.Node) ast.Node {
-	e := &ast.IndexExpression{Token: p.currentToken, Left: left}
+	tok := p.currentToken
 	p.nextToken()
 
-	e.Index = p.parseExpression(precedenceLowest)
-	if !p.expectPeek(token.RBracket) {
+	var index ast.Node
+	if p.currentToken.Type != token.Colon {
+		index = p.parseExpression(precedenceLowest)
+		switch {
+		case p.panicking:
+		case p.peekTokenIs(token.Colon):
+			p.nextToken()
+		case !p.expectPeek(token.RBracket, token.Colon):
+			p.nextToken()
+		}
+	}
+
+	if p.panicking {
+		p.skipTo(token.RBracket)
 		return nil
 	}
 
+	if p.currentToken.Type == token.RBracket {
+		return &ast.IndexExpression{Token: tok, Left: left, Index: index, Close: p.currentToken}
+	}
+
+	return p.parseSliceExpression(&ast.SliceExpression{Token: tok, Left: left, Low: index})
+}
+
+// parseSliceExpression parses the optional upper bound of a slice expression
+func (p *Parser) parseSliceExpression(e *ast.SliceExpression) ast.Node {
+	if !p.peekTokenIs(token.RBracket) {
+		p.nextToken()
+		e.High = p.parseExpression(precedenceLowest)
+	}
+
+	if !p.panicking && !p.expectPeek(token.RBracket) {
+		p.nextToken()
+	}
+
+	if p.panicking {
+		p.skipTo(token.RBracket)
+		return nil
+	}
+
+	e.Close = p.currentToken
 	return e
 }
 
@@ -267,7 +445,7 @@ func (p *Parser) parseIntegerLiteral() ast.Node {
 	var err error
 	l.Value, err = strconv.ParseInt(p.currentToken.Literal, 0, 64)
 	if err != nil {
-		p.error(fmt.Sprintf("invalid integer literal: %s", p.currentToken.Literal))
+		p.error(CodeInvalidLiteral, p.currentToken, nil, "invalid integer literal: %s", p.currentToken.Literal)
 		return nil
 	}
 
@@ -280,7 +458,20 @@ func (p *Parser) parseFloatLiteral() ast.Node {
 	var err error
 	l.Value, err = strconv.ParseFloat(p.currentToken.Literal, 64)
 	if err != nil {
-		p.error(fmt.Sprintf("invalid float literal: %s", p.currentToken.Literal))
+		p.error(CodeInvalidLiteral, p.currentToken, nil, "invalid float literal: %s", p.currentToken.Literal)
+		return nil
+	}
+
+	return l
+}
+
+func (p *Parser) parseDecimalLiteral() ast.Node {
+	l := &ast.DecimalLiteral{Token: p.currentToken}
+
+	var err error
+	l.Value, err = types.ParseDecimal(strings.TrimSuffix(p.currentToken.Literal, "d"))
+	if err != nil {
+		p.error(CodeInvalidLiteral, p.currentToken, nil, "invalid decimal literal: %s", p.currentToken.Literal)
 		return nil
 	}
 
@@ -308,10 +499,67 @@ func (p *Parser) parseNull() ast.Node {
 }
 
 func (p *Parser) parseArrayLiteral() ast.Node {
-	return &ast.ArrayLiteral{
-		Token:    p.currentToken,
-		Elements: p.parseExpressionList(token.RBracket),
+	a := &ast.ArrayLiteral{Token: p.currentToken}
+
+	a.Elements = p.parseExpressionList(token.RBracket)
+	a.Close = p.currentToken
+	return a
+}
+
+func (p *Parser) parseMapLiteral() ast.Node {
+	m := &ast.MapLiteral{Token: p.currentToken, Keys: []ast.Node{}, Values: []ast.Node{}}
+
+	if p.peekTokenIs(token.RBrace) {
+		p.nextToken()
+		m.Close = p.currentToken
+		return m
 	}
+
+	for {
+		p.nextToken()
+
+		if k, v := p.parseMapPair(); !p.panicking {
+			m.Keys = append(m.Keys, k)
+			m.Values = append(m.Values, v)
+
+			if p.peekTokenIs(token.Comma) {
+				p.nextToken()
+				continue
+			}
+			if p.expectPeek(token.RBrace, token.Comma) {
+				m.Close = p.currentToken
+				return m
+			}
+			p.nextToken()
+		}
+
+		// skip the invalid pair and resume from the next delimiter
+		if p.synchronize(token.RBrace) != token.Comma {
+			return nil
+		}
+	}
+}
+
+func (p *Parser) parseMapPair() (ast.Node, ast.Node) {
+	var k ast.Node
+
+	switch p.currentToken.Type {
+	case token.Ident:
+		k = p.parseIdentifier()
+	case token.String:
+		k = p.parseStringLiteral()
+	default:
+		p.unexpected(p.currentToken, token.Ident, token.String)
+		return nil, nil
+	}
+
+	if !p.expectPeek(token.Colon) {
+		p.nextToken()
+		return nil, nil
+	}
+
+	p.nextToken()
+	return k, p.parseExpression(precedenceLowest)
 }
 
 func (p *Parser) nextToken() {
@@ -341,15 +589,55 @@ func (p *Parser) peekTokenIs(t token.Type) bool {
 	return p.peekToken.Type == t
 }
 
-func (p *Parser) expectPeek(t token.Type) bool {
+// expectPeek advances if the peek token is of type t, otherwise it reports an error
+// listing t and any other valid alternatives
+func (p *Parser) expectPeek(t token.Type, alts ...token.Type) bool {
 	if p.peekTokenIs(t) {
 		p.nextToken()
 		return true
 	}
-	p.error(fmt.Sprintf("invalid next token: %d, expected %d", p.peekToken.Type, t))
+
+	p.unexpected(p.peekToken, append(alts, t)...)
 	return false
 }
 
-func (p *Parser) error(err string) {
-	p.errors = append(p.errors, err)
+func (p *Parser) unexpected(t token.Token, expected ...token.Type) {
+	if t.Type == token.Illegal {
+		p.illegal(t)
+		return
+	}
+
+	p.error(CodeUnexpectedToken, t, expected, "unexpected %s, expected %s", describe(t), describeAll(expected))
+}
+
+func (p *Parser) illegal(t token.Token) {
+	// unterminated strings span the opening quote, which is omitted from the literal
+	if t.End.Offset-t.Start.Offset > len(t.Literal) {
+		p.error(CodeIllegalToken, t, nil, "unterminated string literal")
+		return
+	}
+
+	p.error(CodeIllegalToken, t, nil, "illegal token: %s", t.Literal)
+}
+
+// error records a diagnostic unless the parser is already recovering from a previous error
+// or an error has already been reported at the same position
+func (p *Parser) error(code Code, t token.Token, expected []token.Type, format string, args ...any) {
+	if p.panicking {
+		return
+	}
+	p.panicking = true
+
+	if n := len(p.errors); n > 0 && p.errors[n-1].Start == t.Start {
+		return
+	}
+
+	p.errors = append(p.errors, &Error{
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+		Found:    t.Type.String(),
+		Expected: expected,
+		Start:    t.Start,
+		End:      t.End,
+	})
 }
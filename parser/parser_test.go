@@ -1,10 +1,14 @@
 package parser_test
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/stevecallear/mexl/ast"
+	"github.com/stevecallear/mexl/ast/token"
 	"github.com/stevecallear/mexl/parser"
 )
 
@@ -23,6 +27,8 @@ func TestLiteral(t *testing.T) {
 		{".5", 0.5},
 		{"0.5", 0.5},
 		{"5.5", 5.5},
+		{"1.10d", big.NewRat(11, 10)},
+		{"2d", big.NewRat(2, 1)},
 		{`"abc"`, "abc"},
 		{"false", false},
 		{"true", true},
@@ -95,6 +101,10 @@ func TestInfixExpression(t *testing.T) {
 		{`"abc" ne "abc"`, "abc", "ne", "abc"},
 		{`"abc" sw "a"`, "abc", "sw", "a"},
 		{`"abc" ew "c"`, "abc", "ew", "c"},
+		{`"abc" matches "b"`, "abc", "matches", "b"},
+		{`"abc" =~ "b"`, "abc", "=~", "b"},
+		{`"abc" like "a%"`, "abc", "like", "a%"},
+		{`"abc" ilike "A%"`, "abc", "ilike", "A%"},
 		{"1 in [1, 2]", 1, "in", []any{1, 2}},
 		{"true && true", true, "&&", true},
 		{"true && false", true, "&&", false},
@@ -150,6 +160,30 @@ func TestIndexExpression(t *testing.T) {
 	assertLiteral(t, e.Index, idx)
 }
 
+func TestMapLiteral(t *testing.T) {
+	const input = `{a: 1, "b c": "d"}`
+	keys := []string{"a", "b c"}
+	values := []any{1, "d"}
+
+	n := parse(input)
+
+	m, ok := n.(*ast.MapLiteral)
+	if !ok {
+		t.Fatalf("got %T, expected map literal", n)
+	}
+
+	if al, el := len(m.Keys), len(keys); al != el {
+		t.Fatalf("got %d keys, expected %d", al, el)
+	}
+
+	assertIdentifier(t, m.Keys[0], keys[0])
+	assertLiteral(t, m.Keys[1], keys[1])
+
+	for i, v := range m.Values {
+		assertLiteral(t, v, values[i])
+	}
+}
+
 func TestMemberExpression(t *testing.T) {
 	const input = "x.y"
 	const left = "x"
@@ -190,7 +224,26 @@ func TestOperatorPrecedence(t *testing.T) {
 		{"[1, 2, 3][0]", "([1, 2, 3][0])"},
 		{"x + y + z", "((x + y) + z)"},
 		{"x.y.z", "((x.y).z)"},
+		{`x like "a%" and y ilike "b" + z`, "((x like a%) and ((y ilike b) + z))"},
+		{"x[1:2]", "(x[1:2])"},
+		{"x[:-1]", "(x[:(-1)])"},
+		{"x[a ? 1 : 2:]", "(x[(a ? 1 : 2):])"},
+		{"x[:]", "(x[:])"},
+		{"x.y[1:][0]", "(((x.y)[1:])[0])"},
 		{"1 in x.y", "(1 in (x.y))"},
+		{`{a: 1 + 2, "b": {c: [x]}}`, `{a: (1 + 2), "b": {c: [x]}}`},
+		{"{}", "{}"},
+		{"a or b ? 1 + 2 : 3", "((a or b) ? (1 + 2) : 3)"},
+		{"a ? b ? 1 : 2 : c ? 3 : 4", "(a ? (b ? 1 : 2) : (c ? 3 : 4))"},
+		{"if a then 1 else if b then 2 else 3", "(a ? 1 : (b ? 2 : 3))"},
+		{"1 + (if a then 1 else 2)", "(1 + (a ? 1 : 2))"},
+		{"[a ? 1 : 2, 3]", "[(a ? 1 : 2), 3]"},
+		{`x.y ?? "a" eq "b"`, `(((x.y) ?? a) eq b)`},
+		{"x ?? y ?? 1 + 2", "((x ?? y) ?? (1 + 2))"},
+		{"x ?? 1 ? a : b", "((x ?? 1) ? a : b)"},
+		{"any(x, o => o.y gt 1 and o.z)", "any(x, (o => (((o.y) gt 1) and (o.z))))"},
+		{"map(x, a => a ? 1 : 2)", "map(x, (a => (a ? 1 : 2)))"},
+		{"map(x, a => filter(a, b => b))", "map(x, (a => filter(a, (b => b))))"},
 	}
 
 	for _, tt := range tests {
@@ -234,6 +287,14 @@ func TestErrors(t *testing.T) {
 			name:  "invalid index",
 			input: "x[1",
 		},
+		{
+			name:  "invalid slice",
+			input: "x[1:2",
+		},
+		{
+			name:  "invalid slice bound",
+			input: "x[1:2:3]",
+		},
 		{
 			name:  "invalid call",
 			input: "x(1",
@@ -242,6 +303,42 @@ func TestErrors(t *testing.T) {
 			name:  "invalid float",
 			input: "1.2.3",
 		},
+		{
+			name:  "invalid decimal",
+			input: "1.2.3d",
+		},
+		{
+			name:  "missing conditional alternative",
+			input: "a ? 1",
+		},
+		{
+			name:  "missing then",
+			input: "if a 1 else 2",
+		},
+		{
+			name:  "missing else",
+			input: "if a then 1",
+		},
+		{
+			name:  "invalid map key",
+			input: "{1: 2}",
+		},
+		{
+			name:  "missing map value",
+			input: "{a}",
+		},
+		{
+			name:  "unterminated map",
+			input: "{a: 1",
+		},
+		{
+			name:  "invalid lambda parameter",
+			input: "any(x, 1 => true)",
+		},
+		{
+			name:  "missing lambda body",
+			input: "any(x, o =>)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -254,6 +351,262 @@ func TestErrors(t *testing.T) {
 	}
 }
 
+func TestErrorList(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		exp   []*parser.Error
+	}{
+		{
+			name:  "trailing token",
+			input: "x y",
+			exp: []*parser.Error{
+				{
+					Code:     parser.CodeUnexpectedToken,
+					Message:  "unexpected identifier y, expected end of input",
+					Found:    "identifier",
+					Expected: []token.Type{token.EOF},
+					Start:    token.Position{Offset: 2, Line: 1, Column: 3},
+					End:      token.Position{Offset: 3, Line: 1, Column: 4},
+				},
+			},
+		},
+		{
+			name:  "missing expression",
+			input: "1 +",
+			exp: []*parser.Error{
+				{
+					Code:    parser.CodeMissingExpression,
+					Message: "expected expression, found end of input",
+					Found:   "end of input",
+					Start:   token.Position{Offset: 3, Line: 1, Column: 4},
+					End:     token.Position{Offset: 3, Line: 1, Column: 4},
+				},
+			},
+		},
+		{
+			name:  "illegal token",
+			input: "1 $ 2",
+			exp: []*parser.Error{
+				{
+					Code:    parser.CodeIllegalToken,
+					Message: "illegal token: $",
+					Found:   "illegal token",
+					Start:   token.Position{Offset: 2, Line: 1, Column: 3},
+					End:     token.Position{Offset: 3, Line: 1, Column: 4},
+				},
+			},
+		},
+		{
+			name:  "unterminated string",
+			input: `"abc`,
+			exp: []*parser.Error{
+				{
+					Code:    parser.CodeIllegalToken,
+					Message: "unterminated string literal",
+					Found:   "illegal token",
+					Start:   token.Position{Offset: 0, Line: 1, Column: 1},
+					End:     token.Position{Offset: 4, Line: 1, Column: 5},
+				},
+			},
+		},
+		{
+			name:  "invalid literal",
+			input: "1.2.3",
+			exp: []*parser.Error{
+				{
+					Code:    parser.CodeInvalidLiteral,
+					Message: "invalid float literal: 1.2.3",
+					Found:   "float",
+					Start:   token.Position{Offset: 0, Line: 1, Column: 1},
+					End:     token.Position{Offset: 5, Line: 1, Column: 6},
+				},
+			},
+		},
+		{
+			name:  "invalid decimal literal",
+			input: "1.2.3d",
+			exp: []*parser.Error{
+				{
+					Code:    parser.CodeInvalidLiteral,
+					Message: "invalid decimal literal: 1.2.3d",
+					Found:   "decimal",
+					Start:   token.Position{Offset: 0, Line: 1, Column: 1},
+					End:     token.Position{Offset: 6, Line: 1, Column: 7},
+				},
+			},
+		},
+		{
+			name:  "multiple list errors",
+			input: "f(1 2, 3 +)",
+			exp: []*parser.Error{
+				{
+					Code:     parser.CodeUnexpectedToken,
+					Message:  "unexpected integer 2, expected ',' or ')'",
+					Found:    "integer",
+					Expected: []token.Type{token.Comma, token.RParen},
+					Start:    token.Position{Offset: 4, Line: 1, Column: 5},
+					End:      token.Position{Offset: 5, Line: 1, Column: 6},
+				},
+				{
+					Code:    parser.CodeMissingExpression,
+					Message: "expected expression, found ')'",
+					Found:   "')'",
+					Start:   token.Position{Offset: 10, Line: 1, Column: 11},
+					End:     token.Position{Offset: 11, Line: 1, Column: 12},
+				},
+			},
+		},
+		{
+			name:  "nested group errors",
+			input: "[(1 2), $]",
+			exp: []*parser.Error{
+				{
+					Code:     parser.CodeUnexpectedToken,
+					Message:  "unexpected integer 2, expected ')'",
+					Found:    "integer",
+					Expected: []token.Type{token.RParen},
+					Start:    token.Position{Offset: 4, Line: 1, Column: 5},
+					End:      token.Position{Offset: 5, Line: 1, Column: 6},
+				},
+				{
+					Code:    parser.CodeIllegalToken,
+					Message: "illegal token: $",
+					Found:   "illegal token",
+					Start:   token.Position{Offset: 8, Line: 1, Column: 9},
+					End:     token.Position{Offset: 9, Line: 1, Column: 10},
+				},
+			},
+		},
+		{
+			name:  "grouped operands",
+			input: "(1 + ) * (2 - )",
+			exp: []*parser.Error{
+				{
+					Code:    parser.CodeMissingExpression,
+					Message: "expected expression, found ')'",
+					Found:   "')'",
+					Start:   token.Position{Offset: 5, Line: 1, Column: 6},
+					End:     token.Position{Offset: 6, Line: 1, Column: 7},
+				},
+				{
+					Code:    parser.CodeMissingExpression,
+					Message: "expected expression, found ')'",
+					Found:   "')'",
+					Start:   token.Position{Offset: 14, Line: 1, Column: 15},
+					End:     token.Position{Offset: 15, Line: 1, Column: 16},
+				},
+			},
+		},
+		{
+			name:  "grouped logical operands",
+			input: "(1 + ) and (2 - )",
+			exp: []*parser.Error{
+				{
+					Code:    parser.CodeMissingExpression,
+					Message: "expected expression, found ')'",
+					Found:   "')'",
+					Start:   token.Position{Offset: 5, Line: 1, Column: 6},
+					End:     token.Position{Offset: 6, Line: 1, Column: 7},
+				},
+				{
+					Code:    parser.CodeMissingExpression,
+					Message: "expected expression, found ')'",
+					Found:   "')'",
+					Start:   token.Position{Offset: 16, Line: 1, Column: 17},
+					End:     token.Position{Offset: 17, Line: 1, Column: 18},
+				},
+			},
+		},
+		{
+			name:  "call operands",
+			input: "f(1 + ) + g(2 - )",
+			exp: []*parser.Error{
+				{
+					Code:    parser.CodeMissingExpression,
+					Message: "expected expression, found ')'",
+					Found:   "')'",
+					Start:   token.Position{Offset: 6, Line: 1, Column: 7},
+					End:     token.Position{Offset: 7, Line: 1, Column: 8},
+				},
+				{
+					Code:    parser.CodeMissingExpression,
+					Message: "expected expression, found ')'",
+					Found:   "')'",
+					Start:   token.Position{Offset: 16, Line: 1, Column: 17},
+					End:     token.Position{Offset: 17, Line: 1, Column: 18},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parser.New(tt.input).Parse()
+
+			var act parser.ErrorList
+			if !errors.As(err, &act) {
+				t.Fatalf("got %T, expected parser.ErrorList", err)
+			}
+
+			if !reflect.DeepEqual([]*parser.Error(act), tt.exp) {
+				t.Errorf("got %v, expected %v", act, tt.exp)
+			}
+
+			for _, e := range tt.exp {
+				if !errors.Is(err, e.Code) {
+					t.Errorf("got false, expected errors.Is %v", e.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestPosition(t *testing.T) {
+	tests := []struct {
+		input string
+		start int
+		end   int
+	}{
+		{"abc", 0, 3},
+		{`"abc"`, 0, 5},
+		{"-1", 0, 2},
+		{"1 + 2", 0, 5},
+		{"[1, 2]", 0, 6},
+		{"x[1]", 0, 4},
+		{"x.y", 0, 3},
+		{"fn(1, 2)", 0, 8},
+		{" (1 + 2) ", 2, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			n := parse(tt.input)
+
+			if act := n.Pos().Offset; act != tt.start {
+				t.Errorf("got %d, expected %d", act, tt.start)
+			}
+			if act := n.End().Offset; act != tt.end {
+				t.Errorf("got %d, expected %d", act, tt.end)
+			}
+		})
+	}
+}
+
+func TestErrorPosition(t *testing.T) {
+	_, err := parser.New("x +\n  $").Parse()
+
+	var act *parser.Error
+	if !errors.As(err, &act) {
+		t.Fatalf("got %T, expected parser.Error", err)
+	}
+
+	exp := token.Position{Offset: 6, Line: 2, Column: 3}
+	if act.Start != exp {
+		t.Errorf("got %v, expected %v", act.Start, exp)
+	}
+}
+
 func assertIdentifier(t *testing.T, e ast.Node, value string) {
 	t.Helper()
 
@@ -307,6 +660,8 @@ func assertLiteral(t *testing.T, n ast.Node, value any) bool {
 		return assertIntegerLiteral(t, n, tv)
 	case float64:
 		return assertFloatLiteral(t, n, tv)
+	case *big.Rat:
+		return assertDecimalLiteral(t, n, tv)
 	case bool:
 		return assertBoolean(t, n, tv)
 	case []any:
@@ -358,6 +713,23 @@ func assertFloatLiteral(t *testing.T, n ast.Node, value float64) bool {
 	return true
 }
 
+func assertDecimalLiteral(t *testing.T, n ast.Node, value *big.Rat) bool {
+	t.Helper()
+
+	l, ok := n.(*ast.DecimalLiteral)
+	if !ok {
+		t.Errorf("got %T, expected decimal literal", n)
+		return false
+	}
+
+	if l.Value.Cmp(value) != 0 {
+		t.Errorf("got %v, expected %v", l.Value, value)
+		return false
+	}
+
+	return true
+}
+
 func assertBoolean(t *testing.T, n ast.Node, value bool) bool {
 	t.Helper()
 
@@ -0,0 +1,83 @@
+package mexl
+
+import (
+	"context"
+	"sync"
+
+	"github.com/stevecallear/mexl/types"
+	"github.com/stevecallear/mexl/vm"
+)
+
+type (
+	// Runner runs a compiled program using pooled VMs
+	// It is safe for concurrent use and avoids allocating a VM and stack for each run.
+	Runner struct {
+		program *vm.Program
+		opts    *options
+		pool    sync.Pool
+	}
+
+	runState struct {
+		vm  *vm.VM
+		env *types.LazyMap
+	}
+)
+
+// NewRunner returns a new runner for the compiled program
+// Run options are applied to every run.
+func NewRunner(p *vm.Program, opts ...Option) *Runner {
+	r := &Runner{
+		program: p,
+		opts:    newOptions(opts),
+	}
+
+	convert := types.ToObject
+	if r.opts.reflect {
+		convert = types.ReflectObject
+	}
+
+	r.pool.New = func() any {
+		return &runState{
+			vm:  vm.New(p, nil, r.opts.vmOpts...),
+			env: types.NewLazyMap(nil, convert),
+		}
+	}
+
+	return r
+}
+
+// Run runs the program with the specified environment
+func (r *Runner) Run(env map[string]any) (any, error) {
+	return r.RunContext(context.Background(), env)
+}
+
+// RunContext runs the program with the specified environment, returning an error if the context is cancelled
+func (r *Runner) RunContext(ctx context.Context, env map[string]any) (any, error) {
+	s := r.pool.Get().(*runState)
+	defer r.pool.Put(s)
+
+	s.env.Reset(env)
+	defer s.env.Reset(nil)
+
+	out, err := r.run(ctx, s.vm, s.env)
+	if cerr := s.env.Err(); cerr != nil {
+		return nil, cerr
+	}
+
+	return out, err
+}
+
+// RunResolver runs the program, resolving identifiers using the specified resolver
+func (r *Runner) RunResolver(res types.Resolver) (any, error) {
+	s := r.pool.Get().(*runState)
+	defer r.pool.Put(s)
+
+	return r.run(context.Background(), s.vm, res)
+}
+
+func (r *Runner) run(ctx context.Context, v *vm.VM, res types.Resolver) (any, error) {
+	v.Reset(res)
+	defer v.Reset(nil)
+
+	return result(ctx, r.program, v, r.opts)
+}
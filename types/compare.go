@@ -0,0 +1,121 @@
+package types
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// Compare returns a total ordering of the objects, returning -1, 0 or 1
+// Objects of different types are ordered by type, with numbers compared by value regardless of type.
+func Compare(a, b Object) int {
+	if c := cmp.Compare(rank(a), rank(b)); c != 0 {
+		return c
+	}
+
+	switch a := a.(type) {
+	case *Boolean:
+		bv := b.(*Boolean).Value
+		switch {
+		case a.Value == bv:
+			return 0
+		case a.Value:
+			return 1
+		default:
+			return -1
+		}
+
+	case *Integer, *Float, *Decimal:
+		return compareNumbers(a, b)
+
+	case *String:
+		return strings.Compare(a.Value, b.(*String).Value)
+
+	case *Time:
+		return a.Value.Compare(b.(*Time).Value)
+
+	case *Duration:
+		return cmp.Compare(a.Value, b.(*Duration).Value)
+
+	case Array:
+		bv := b.(Array)
+		for i := range min(len(a), len(bv)) {
+			if c := Compare(a[i], bv[i]); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(len(a), len(bv))
+
+	case Map:
+		bv := b.(Map)
+		ak, bk := sortedKeys(a), sortedKeys(bv)
+		for i := range min(len(ak), len(bk)) {
+			if c := strings.Compare(ak[i], bk[i]); c != 0 {
+				return c
+			}
+			if c := Compare(a[ak[i]], bv[bk[i]]); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(len(ak), len(bk))
+
+	default:
+		// nulls and functions are considered equal
+		return 0
+	}
+}
+
+// rank returns the sort order of the object type
+func rank(o Object) int {
+	switch o.(type) {
+	case *Null:
+		return 0
+	case *Boolean:
+		return 1
+	case *Integer, *Float, *Decimal:
+		return 2
+	case *String:
+		return 3
+	case *Time:
+		return 4
+	case *Duration:
+		return 5
+	case Array:
+		return 6
+	case Map:
+		return 7
+	default:
+		return 8
+	}
+}
+
+// compareNumbers compares numbers of any type by value
+func compareNumbers(a, b Object) int {
+	if ai, ok := a.(*Integer); ok {
+		if bi, ok := b.(*Integer); ok {
+			return cmp.Compare(ai.Value, bi.Value)
+		}
+	}
+
+	if a.Type() == TypeDecimal || b.Type() == TypeDecimal {
+		ad, aok := Convert(a, TypeDecimal)
+		bd, bok := Convert(b, TypeDecimal)
+		if aok && bok {
+			return ad.(*Decimal).Value.Cmp(bd.(*Decimal).Value)
+		}
+		// infinite and NaN floats cannot be represented as decimals
+	}
+
+	af, _ := Convert(a, TypeFloat)
+	bf, _ := Convert(b, TypeFloat)
+	return cmp.Compare(af.(*Float).Value, bf.(*Float).Value)
+}
+
+func sortedKeys(m Map) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	slices.Sort(ks)
+	return ks
+}
@@ -0,0 +1,121 @@
+package types_test
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stevecallear/mexl/types"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		a    types.Object
+		b    types.Object
+		exp  int
+	}{
+		{
+			name: "null",
+			a:    &types.Null{},
+			b:    &types.Null{},
+			exp:  0,
+		},
+		{
+			name: "type order",
+			a:    &types.Boolean{Value: true},
+			b:    &types.Integer{Value: 0},
+			exp:  -1,
+		},
+		{
+			name: "boolean",
+			a:    &types.Boolean{Value: true},
+			b:    &types.Boolean{Value: false},
+			exp:  1,
+		},
+		{
+			name: "integer",
+			a:    &types.Integer{Value: 1},
+			b:    &types.Integer{Value: 2},
+			exp:  -1,
+		},
+		{
+			name: "integer float",
+			a:    &types.Integer{Value: 2},
+			b:    &types.Float{Value: 1.5},
+			exp:  1,
+		},
+		{
+			name: "float decimal",
+			a:    &types.Float{Value: 0.5},
+			b:    &types.Decimal{Value: big.NewRat(1, 2)},
+			exp:  0,
+		},
+		{
+			name: "infinite float decimal",
+			a:    &types.Float{Value: math.Inf(1)},
+			b:    &types.Decimal{Value: big.NewRat(1, 2)},
+			exp:  1,
+		},
+		{
+			name: "nan",
+			a:    &types.Float{Value: math.NaN()},
+			b:    &types.Integer{Value: math.MinInt64},
+			exp:  -1,
+		},
+		{
+			name: "string",
+			a:    &types.String{Value: "b"},
+			b:    &types.String{Value: "a"},
+			exp:  1,
+		},
+		{
+			name: "time",
+			a:    &types.Time{Value: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			b:    &types.Time{Value: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+			exp:  -1,
+		},
+		{
+			name: "duration",
+			a:    &types.Duration{Value: time.Minute},
+			b:    &types.Duration{Value: time.Second},
+			exp:  1,
+		},
+		{
+			name: "array elements",
+			a:    types.Array{&types.Integer{Value: 1}, &types.Integer{Value: 3}},
+			b:    types.Array{&types.Integer{Value: 1}, &types.Integer{Value: 2}},
+			exp:  1,
+		},
+		{
+			name: "array length",
+			a:    types.Array{&types.Integer{Value: 1}},
+			b:    types.Array{&types.Integer{Value: 1}, &types.Integer{Value: 2}},
+			exp:  -1,
+		},
+		{
+			name: "map keys",
+			a:    types.Map{"a": &types.Integer{Value: 2}},
+			b:    types.Map{"b": &types.Integer{Value: 1}},
+			exp:  -1,
+		},
+		{
+			name: "map values",
+			a:    types.Map{"a": &types.Integer{Value: 2}},
+			b:    types.Map{"a": &types.Integer{Value: 1}},
+			exp:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if act := types.Compare(tt.a, tt.b); act != tt.exp {
+				t.Errorf("got %d, expected %d", act, tt.exp)
+			}
+			if act := types.Compare(tt.b, tt.a); act != -tt.exp {
+				t.Errorf("got %d reversed, expected %d", act, -tt.exp)
+			}
+		})
+	}
+}
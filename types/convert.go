@@ -1,14 +1,29 @@
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"math/big"
+	"time"
+)
 
 var defaults = map[Type]Object{
-	TypeInteger: new(Integer),
-	TypeFloat:   new(Float),
-	TypeString:  new(String),
-	TypeBoolean: new(Boolean),
-	TypeArray:   Array{},
-	TypeMap:     Map{},
+	TypeInteger:  new(Integer),
+	TypeFloat:    new(Float),
+	TypeDecimal:  &Decimal{Value: new(big.Rat)},
+	TypeString:   new(String),
+	TypeBoolean:  new(Boolean),
+	TypeArray:    Array{},
+	TypeMap:      Map{},
+	TypeTime:     new(Time),
+	TypeDuration: new(Duration),
+}
+
+// timeLayouts are the layouts accepted when converting a string to a time
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	time.DateOnly,
 }
 
 func Convert(o Object, t Type) (Object, bool) {
@@ -20,8 +35,48 @@ func Convert(o Object, t Type) (Object, bool) {
 	case ot == TypeInteger && t == TypeFloat:
 		return &Float{Value: float64(o.(*Integer).Value)}, true
 
+	case ot == TypeInteger && t == TypeDecimal:
+		return &Decimal{Value: new(big.Rat).SetInt64(o.(*Integer).Value)}, true
+
+	case ot == TypeFloat && t == TypeDecimal:
+		v, ok := FloatToDecimal(o.(*Float).Value)
+		if !ok {
+			return o, false
+		}
+		return &Decimal{Value: v}, true
+
+	case ot == TypeDecimal && t == TypeFloat:
+		v, _ := o.(*Decimal).Value.Float64()
+		return &Float{Value: v}, true
+
+	case ot == TypeString && t == TypeDecimal:
+		v, err := ParseDecimal(o.(*String).Value)
+		if err != nil {
+			return o, false
+		}
+		return &Decimal{Value: v}, true
+
+	case ot == TypeString && t == TypeTime:
+		v, err := ParseTime(o.(*String).Value)
+		if err != nil {
+			return o, false
+		}
+		return &Time{Value: v}, true
+
+	case ot == TypeString && t == TypeDuration:
+		v, err := time.ParseDuration(o.(*String).Value)
+		if err != nil {
+			return o, false
+		}
+		return &Duration{Value: v}, true
+
 	case ot == TypeNull:
-		return getDefault(t), true
+		// types without a default value, such as functions, cannot be converted from null
+		d, ok := defaults[t]
+		if !ok {
+			return o, false
+		}
+		return d, true
 
 	default:
 		return o, false
@@ -33,25 +88,45 @@ func Coerce(left, right Object) (Object, Object) {
 
 	switch {
 	case lt == TypeNull && rt != TypeNull:
-		left = getDefault(rt)
+		if d, ok := defaults[rt]; ok {
+			left = d
+		}
 
 	case lt != TypeNull && rt == TypeNull:
-		right = getDefault(lt)
+		if d, ok := defaults[lt]; ok {
+			right = d
+		}
 
 	case lt == TypeInteger && rt == TypeFloat:
 		left, _ = Convert(left, rt)
 
 	case lt == TypeFloat && rt == TypeInteger:
 		right, _ = Convert(right, lt)
+
+	case lt == TypeDecimal && (rt == TypeInteger || rt == TypeFloat):
+		right, _ = Convert(right, lt)
+
+	case (lt == TypeInteger || lt == TypeFloat) && rt == TypeDecimal:
+		left, _ = Convert(left, rt)
+
+	case (lt == TypeTime || lt == TypeDuration) && rt == TypeString:
+		right, _ = Convert(right, lt)
+
+	case lt == TypeString && (rt == TypeTime || rt == TypeDuration):
+		left, _ = Convert(left, rt)
 	}
 
 	return left, right
 }
 
-func getDefault(t Type) Object {
-	o, ok := defaults[t]
-	if !ok {
-		panic(fmt.Errorf("no default for type: %s", t))
+// ParseTime parses the string as an RFC 3339 time, a date and time without a zone or a date
+// Times without a zone are parsed as UTC.
+func ParseTime(s string) (time.Time, error) {
+	for _, l := range timeLayouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t, nil
+		}
 	}
-	return o
+
+	return time.Time{}, fmt.Errorf("invalid time: %s", s)
 }
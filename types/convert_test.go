@@ -1,8 +1,10 @@
 package types_test
 
 import (
+	"math/big"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stevecallear/mexl/types"
 )
@@ -71,6 +73,83 @@ func TestConvert(t *testing.T) {
 			exp:  types.Map{},
 			ok:   true,
 		},
+		{
+			name: "null to time",
+			obj:  new(types.Null),
+			typ:  types.TypeTime,
+			exp:  new(types.Time),
+			ok:   true,
+		},
+		{
+			name: "string to time",
+			obj:  &types.String{Value: "2024-01-02T10:00:00Z"},
+			typ:  types.TypeTime,
+			exp:  &types.Time{Value: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)},
+			ok:   true,
+		},
+		{
+			name: "date string to time",
+			obj:  &types.String{Value: "2024-01-02"},
+			typ:  types.TypeTime,
+			exp:  &types.Time{Value: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+			ok:   true,
+		},
+		{
+			name: "invalid string to time",
+			obj:  &types.String{Value: "a"},
+			typ:  types.TypeTime,
+			exp:  &types.String{Value: "a"},
+			ok:   false,
+		},
+		{
+			name: "string to duration",
+			obj:  &types.String{Value: "1h30m"},
+			typ:  types.TypeDuration,
+			exp:  &types.Duration{Value: 90 * time.Minute},
+			ok:   true,
+		},
+		{
+			name: "invalid string to duration",
+			obj:  &types.String{Value: "a"},
+			typ:  types.TypeDuration,
+			exp:  &types.String{Value: "a"},
+			ok:   false,
+		},
+		{
+			name: "integer to decimal",
+			obj:  &types.Integer{Value: 2},
+			typ:  types.TypeDecimal,
+			exp:  &types.Decimal{Value: new(big.Rat).SetInt64(2)},
+			ok:   true,
+		},
+		{
+			name: "float to decimal",
+			obj:  &types.Float{Value: 0.1},
+			typ:  types.TypeDecimal,
+			exp:  &types.Decimal{Value: decimal("0.1")},
+			ok:   true,
+		},
+		{
+			name: "decimal to float",
+			obj:  &types.Decimal{Value: decimal("1.5")},
+			typ:  types.TypeFloat,
+			exp:  &types.Float{Value: 1.5},
+			ok:   true,
+		},
+		{
+			name: "string to decimal",
+			obj:  &types.String{Value: "1.10"},
+			typ:  types.TypeDecimal,
+			exp:  &types.Decimal{Value: decimal("1.10")},
+			ok:   true,
+		},
+		{
+			name: "invalid string to decimal",
+			obj:  &types.String{Value: "1/3"},
+			typ:  types.TypeDecimal,
+			exp:  &types.String{Value: "1/3"},
+			ok:   false,
+		},
 		{
 			name: "invalid",
 			obj:  &types.String{Value: "1"},
@@ -159,6 +238,61 @@ func TestCoerce(t *testing.T) {
 				right: &types.Float{Value: 1.0},
 			},
 		},
+		{
+			name: "decimal integer coerce",
+			input: pair{
+				left:  &types.Decimal{Value: decimal("1.5")},
+				right: &types.Integer{Value: 1},
+			},
+			exp: pair{
+				left:  &types.Decimal{Value: decimal("1.5")},
+				right: &types.Decimal{Value: new(big.Rat).SetInt64(1)},
+			},
+		},
+		{
+			name: "float decimal coerce",
+			input: pair{
+				left:  &types.Float{Value: 0.5},
+				right: &types.Decimal{Value: decimal("1.5")},
+			},
+			exp: pair{
+				left:  &types.Decimal{Value: decimal("0.5")},
+				right: &types.Decimal{Value: decimal("1.5")},
+			},
+		},
+		{
+			name: "time string coerce",
+			input: pair{
+				left:  &types.Time{Value: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+				right: &types.String{Value: "2024-01-01"},
+			},
+			exp: pair{
+				left:  &types.Time{Value: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+				right: &types.Time{Value: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name: "string duration coerce",
+			input: pair{
+				left:  &types.String{Value: "1m"},
+				right: &types.Duration{Value: time.Second},
+			},
+			exp: pair{
+				left:  &types.Duration{Value: time.Minute},
+				right: &types.Duration{Value: time.Second},
+			},
+		},
+		{
+			name: "null duration coerce",
+			input: pair{
+				left:  new(types.Null),
+				right: &types.Duration{Value: time.Second},
+			},
+			exp: pair{
+				left:  new(types.Duration),
+				right: &types.Duration{Value: time.Second},
+			},
+		},
 		{
 			name: "invalid ignore",
 			input: pair{
@@ -185,14 +319,25 @@ func TestCoerce(t *testing.T) {
 }
 
 func TestConvertDefault(t *testing.T) {
-	t.Run("should panic on invalid type", func(t *testing.T) {
-
-		defer func() {
-			if err := recover(); err == nil { // err is nil
-				t.Error("got nil, expected panic")
-			}
-		}()
+	t.Run("should not convert null to a type without a default", func(t *testing.T) {
+		if _, ok := types.Convert(new(types.Null), types.TypeFunc); ok {
+			t.Error("got true, expected false")
+		}
+	})
 
-		types.Convert(new(types.Null), types.TypeFunc)
+	t.Run("should not coerce null to a type without a default", func(t *testing.T) {
+		fn := types.Func(nil)
+		l, r := types.Coerce(new(types.Null), fn)
+		if l.Type() != types.TypeNull || r.Type() != types.TypeFunc {
+			t.Errorf("got %s, %s, expected %s, %s", l.Type(), r.Type(), types.TypeNull, types.TypeFunc)
+		}
 	})
 }
+
+func decimal(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		panic("invalid decimal: " + s)
+	}
+	return r
+}
@@ -0,0 +1,44 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DecimalPrecision is the number of decimal places used to format decimals that cannot be represented exactly
+const DecimalPrecision = 16
+
+// ParseDecimal parses the string as an exact decimal
+func ParseDecimal(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || strings.ContainsAny(s, "/") {
+		return nil, fmt.Errorf("invalid decimal: %s", s)
+	}
+	return r, nil
+}
+
+// FloatToDecimal returns the decimal with the shortest representation of the float
+func FloatToDecimal(f float64) (*big.Rat, bool) {
+	return new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// FormatDecimal returns the decimal representation of the value
+// Values that cannot be represented exactly are rounded to DecimalPrecision places.
+func FormatDecimal(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+
+	prec, exact := r.FloatPrec()
+	if !exact {
+		prec = DecimalPrecision
+	}
+
+	s := r.FloatString(prec)
+	if !exact {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
@@ -0,0 +1,61 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/stevecallear/mexl/types"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		exp   string
+		err   bool
+	}{
+		{
+			name:  "integer",
+			input: "10",
+			exp:   "10",
+		},
+		{
+			name:  "fraction",
+			input: "-1.50",
+			exp:   "-1.5",
+		},
+		{
+			name:  "exponent",
+			input: "1e-3",
+			exp:   "0.001",
+		},
+		{
+			name:  "ratio",
+			input: "1/3",
+			err:   true,
+		},
+		{
+			name:  "invalid",
+			input: "a",
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, err := types.ParseDecimal(tt.input)
+			if err != nil {
+				if !tt.err {
+					t.Errorf("got %v, expected nil", err)
+				}
+				return
+			}
+			if tt.err {
+				t.Fatal("got nil, expected an error")
+			}
+
+			if s := types.FormatDecimal(act); s != tt.exp {
+				t.Errorf("got %s, expected %s", s, tt.exp)
+			}
+		})
+	}
+}
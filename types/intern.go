@@ -0,0 +1,43 @@
+package types
+
+const (
+	minInternedInteger = -128
+	maxInternedInteger = 1024
+)
+
+var (
+	integers     [maxInternedInteger - minInternedInteger]Integer
+	asciiStrings [128]String
+	emptyString  = &String{}
+)
+
+func init() {
+	for i := range integers {
+		integers[i].Value = int64(i + minInternedInteger)
+	}
+	for i := range asciiStrings {
+		asciiStrings[i].Value = string(rune(i))
+	}
+}
+
+// NewInteger returns an integer object with the specified value
+// Small integers are interned to avoid allocation, so objects must not be modified.
+func NewInteger(v int64) *Integer {
+	if v >= minInternedInteger && v < maxInternedInteger {
+		return &integers[v-minInternedInteger]
+	}
+	return &Integer{Value: v}
+}
+
+// NewString returns a string object with the specified value
+// Empty and single character ASCII strings are interned to avoid allocation, so objects must not be modified.
+func NewString(v string) *String {
+	switch {
+	case v == "":
+		return emptyString
+	case len(v) == 1 && v[0] < 128:
+		return &asciiStrings[v[0]]
+	default:
+		return &String{Value: v}
+	}
+}
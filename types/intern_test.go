@@ -0,0 +1,58 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/stevecallear/mexl/types"
+)
+
+func TestNewInteger(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    int64
+		interned bool
+	}{
+		{"zero", 0, true},
+		{"min", -128, true},
+		{"max", 1023, true},
+		{"below min", -129, false},
+		{"above max", 1024, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := types.NewInteger(tt.input), types.NewInteger(tt.input)
+			if a.Value != tt.input {
+				t.Errorf("got %d, expected %d", a.Value, tt.input)
+			}
+			if act := a == b; act != tt.interned {
+				t.Errorf("got %v, expected %v", act, tt.interned)
+			}
+		})
+	}
+}
+
+func TestNewString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		interned bool
+	}{
+		{"empty", "", true},
+		{"ascii", "a", true},
+		{"non ascii", "é", false},
+		{"multiple characters", "ab", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := types.NewString(tt.input), types.NewString(tt.input)
+			if a.Value != tt.input {
+				t.Errorf("got %s, expected %s", a.Value, tt.input)
+			}
+			if act := a == b; act != tt.interned {
+				t.Errorf("got %v, expected %v", act, tt.interned)
+			}
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package types
 
 import (
+	"context"
 	"fmt"
+	"math/big"
+	"time"
 )
 
 func ToMap(m map[string]any) (Map, error) {
@@ -16,35 +19,38 @@ func ToMap(m map[string]any) (Map, error) {
 func ToObject(a any) (Object, error) {
 	var err error
 	switch v := a.(type) {
+	case nil:
+		return &Null{}, nil
+
 	case int:
-		return &Integer{Value: int64(v)}, nil
+		return NewInteger(int64(v)), nil
 
 	case int8:
-		return &Integer{Value: int64(v)}, nil
+		return NewInteger(int64(v)), nil
 
 	case int16:
-		return &Integer{Value: int64(v)}, nil
+		return NewInteger(int64(v)), nil
 
 	case int32:
-		return &Integer{Value: int64(v)}, nil
+		return NewInteger(int64(v)), nil
 
 	case int64:
-		return &Integer{Value: v}, nil
+		return NewInteger(v), nil
 
 	case uint:
-		return &Integer{Value: int64(v)}, nil
+		return NewInteger(int64(v)), nil
 
 	case uint8:
-		return &Integer{Value: int64(v)}, nil
+		return NewInteger(int64(v)), nil
 
 	case uint16:
-		return &Integer{Value: int64(v)}, nil
+		return NewInteger(int64(v)), nil
 
 	case uint32:
-		return &Integer{Value: int64(v)}, nil
+		return NewInteger(int64(v)), nil
 
 	case uint64:
-		return &Integer{Value: int64(v)}, nil
+		return NewInteger(int64(v)), nil
 
 	case float32:
 		return &Float{Value: float64(v)}, nil
@@ -52,18 +58,36 @@ func ToObject(a any) (Object, error) {
 	case float64:
 		return &Float{Value: v}, nil
 
+	case *big.Rat:
+		if v == nil {
+			return &Null{}, nil
+		}
+		return &Decimal{Value: new(big.Rat).Set(v)}, nil
+
 	case string:
-		return &String{Value: v}, nil
+		return NewString(v), nil
 
 	case bool:
 		return &Boolean{Value: v}, nil
 
+	case time.Time:
+		return &Time{Value: v}, nil
+
+	case time.Duration:
+		return &Duration{Value: v}, nil
+
 	case Func:
 		return v, nil
 
 	case func(...Object) (Object, error):
 		return Func(v), nil
 
+	case ContextFunc:
+		return v, nil
+
+	case func(context.Context, ...Object) (Object, error):
+		return ContextFunc(v), nil
+
 	case []any:
 		a := make(Array, len(v))
 		for i, e := range v {
@@ -103,9 +127,18 @@ func ToNative(o Object) (_ any, err error) {
 	case *Float:
 		return t.Value, nil
 
+	case *Decimal:
+		return new(big.Rat).Set(t.Value), nil
+
 	case *String:
 		return t.Value, nil
 
+	case *Time:
+		return t.Value, nil
+
+	case *Duration:
+		return t.Value, nil
+
 	case Array:
 		v := make([]any, len(t))
 		for i, e := range t {
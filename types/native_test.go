@@ -1,8 +1,10 @@
 package types_test
 
 import (
+	"math/big"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stevecallear/mexl/types"
 )
@@ -89,6 +91,11 @@ func TestToObject(t *testing.T) {
 		exp   types.Object
 		err   bool
 	}{
+		{
+			name:  "nil",
+			input: nil,
+			exp:   &types.Null{},
+		},
 		{
 			name:  "int",
 			input: int(1),
@@ -154,6 +161,26 @@ func TestToObject(t *testing.T) {
 			input: true,
 			exp:   &types.Boolean{Value: true},
 		},
+		{
+			name:  "decimal",
+			input: decimal("1.10"),
+			exp:   &types.Decimal{Value: decimal("1.1")},
+		},
+		{
+			name:  "nil decimal",
+			input: (*big.Rat)(nil),
+			exp:   &types.Null{},
+		},
+		{
+			name:  "time",
+			input: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			exp:   &types.Time{Value: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:  "duration",
+			input: time.Minute,
+			exp:   &types.Duration{Value: time.Minute},
+		},
 		{
 			name:  "string",
 			input: "abc",
@@ -256,6 +283,21 @@ func TestToNative(t *testing.T) {
 			input: &types.Boolean{Value: true},
 			exp:   true,
 		},
+		{
+			name:  "decimal",
+			input: &types.Decimal{Value: decimal("1.1")},
+			exp:   decimal("1.1"),
+		},
+		{
+			name:  "time",
+			input: &types.Time{Value: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+			exp:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "duration",
+			input: &types.Duration{Value: time.Minute},
+			exp:   time.Minute,
+		},
 		{
 			name:  "map",
 			input: objectMap,
@@ -0,0 +1,302 @@
+package types
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	field struct {
+		name  string
+		index []int
+	}
+
+	// visit identifies a pointer, slice or map on the current conversion path
+	visit struct {
+		ptr uintptr
+		typ reflect.Type
+		len int
+	}
+)
+
+// maxReflectDepth is the maximum number of nested pointers, slices and maps that are converted
+const maxReflectDepth = 1000
+
+var (
+	fieldCache sync.Map // map[reflect.Type][]field
+
+	typeObject          = reflect.TypeFor[Object]()
+	typeTextMarshaler   = reflect.TypeFor[encoding.TextMarshaler]()
+	typeStringer        = reflect.TypeFor[fmt.Stringer]()
+	typeTime            = reflect.TypeFor[time.Time]()
+	typeRat             = reflect.TypeFor[*big.Rat]()
+	typeDuration        = reflect.TypeFor[time.Duration]()
+	typeFuncDeclaration = reflect.TypeFor[func(...Object) (Object, error)]()
+	typeContextFuncDecl = reflect.TypeFor[func(context.Context, ...Object) (Object, error)]()
+)
+
+// ReflectMap converts the map to an object map using reflection
+// See ReflectObject for the supported types.
+func ReflectMap(m map[string]any) (Map, error) {
+	o, err := ReflectObject(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.(Map), nil
+}
+
+// ReflectObject converts the value to an object using reflection
+// In addition to the types supported by ToObject, it supports structs, pointers, typed slices and arrays,
+// maps with string keys and encoding.TextMarshaler or fmt.Stringer values.
+// Struct fields are named using the mexl tag, then the json tag, then the field name. Fields tagged "-" are skipped.
+func ReflectObject(a any) (Object, error) {
+	if a == nil {
+		return &Null{}, nil
+	}
+
+	return reflectValue(reflect.ValueOf(a), map[visit]struct{}{})
+}
+
+// reflectValue converts the value, returning an error if a pointer, slice or map refers to itself
+// The seen map contains the references on the current path, in the same way as encoding/json.
+func reflectValue(v reflect.Value, seen map[visit]struct{}) (Object, error) {
+	if !v.IsValid() {
+		return &Null{}, nil
+	}
+
+	t := v.Type()
+
+	switch {
+	case t.Implements(typeObject):
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return &Null{}, nil
+		}
+		return v.Interface().(Object), nil
+
+	case t.ConvertibleTo(typeFuncDeclaration) && t.Kind() == reflect.Func:
+		if v.IsNil() {
+			return &Null{}, nil
+		}
+		return Func(v.Convert(typeFuncDeclaration).Interface().(func(...Object) (Object, error))), nil
+
+	case t.ConvertibleTo(typeContextFuncDecl) && t.Kind() == reflect.Func:
+		if v.IsNil() {
+			return &Null{}, nil
+		}
+		return ContextFunc(v.Convert(typeContextFuncDecl).Interface().(func(context.Context, ...Object) (Object, error))), nil
+
+	case t == typeRat:
+		return ToObject(v.Interface())
+
+	case t == typeTime:
+		return &Time{Value: v.Interface().(time.Time)}, nil
+
+	case t == typeDuration:
+		return &Duration{Value: v.Interface().(time.Duration)}, nil
+
+	case t.Implements(typeTextMarshaler):
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return &Null{}, nil
+		}
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return NewString(string(b)), nil
+
+	case t.Implements(typeStringer):
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return &Null{}, nil
+		}
+		return NewString(v.Interface().(fmt.Stringer).String()), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return &Boolean{Value: v.Bool()}, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return NewInteger(v.Int()), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return NewInteger(int64(v.Uint())), nil
+
+	case reflect.Float32, reflect.Float64:
+		return &Float{Value: v.Float()}, nil
+
+	case reflect.String:
+		return NewString(v.String()), nil
+
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return &Null{}, nil
+		}
+		if v.Kind() == reflect.Interface {
+			return reflectValue(v.Elem(), seen)
+		}
+		return reflectRef(v, seen, 0, func() (Object, error) {
+			return reflectValue(v.Elem(), seen)
+		})
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return &Null{}, nil
+		}
+		conv := func() (Object, error) {
+			a := make(Array, v.Len())
+			for i := range a {
+				o, err := reflectValue(v.Index(i), seen)
+				if err != nil {
+					return nil, err
+				}
+				a[i] = o
+			}
+			return a, nil
+		}
+		if v.Kind() == reflect.Array || v.Len() == 0 {
+			return conv()
+		}
+		return reflectRef(v, seen, v.Len(), conv)
+
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("invalid map key type: %s", t.Key())
+		}
+		if v.IsNil() {
+			return &Null{}, nil
+		}
+		return reflectRef(v, seen, 0, func() (Object, error) {
+			m := make(Map, v.Len())
+			for it := v.MapRange(); it.Next(); {
+				o, err := reflectValue(it.Value(), seen)
+				if err != nil {
+					return nil, err
+				}
+				m[it.Key().String()] = o
+			}
+			return m, nil
+		})
+
+	case reflect.Struct:
+		fs := structFields(t)
+		m := make(Map, len(fs))
+		for _, f := range fs {
+			fv, err := v.FieldByIndexErr(f.index)
+			if err != nil {
+				// nil embedded pointer
+				continue
+			}
+			o, err := reflectValue(fv, seen)
+			if err != nil {
+				return nil, err
+			}
+			m[f.name] = o
+		}
+		return m, nil
+
+	default:
+		return nil, fmt.Errorf("invalid type: %s", t)
+	}
+}
+
+// reflectRef converts the pointer, slice or map using fn, returning an error if it is already on the current path
+func reflectRef(v reflect.Value, seen map[visit]struct{}, n int, fn func() (Object, error)) (Object, error) {
+	k := visit{ptr: v.Pointer(), typ: v.Type(), len: n}
+	if _, ok := seen[k]; ok {
+		return nil, fmt.Errorf("encountered a cycle via %s", v.Type())
+	}
+	if len(seen) >= maxReflectDepth {
+		return nil, fmt.Errorf("maximum depth exceeded: %d", maxReflectDepth)
+	}
+
+	seen[k] = struct{}{}
+	defer delete(seen, k)
+
+	return fn()
+}
+
+// structFields returns the exported fields of the struct type, including promoted fields of embedded structs
+func structFields(t reflect.Type) []field {
+	if fs, ok := fieldCache.Load(t); ok {
+		return fs.([]field)
+	}
+
+	var fs []field
+	depths := map[string]int{} // shallower fields take precedence over promoted fields
+
+	path := map[reflect.Type]bool{} // embedded types on the current walk path
+
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		path[t] = true
+		defer delete(path, t)
+
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			name, tagged := fieldName(sf)
+			if name == "-" {
+				continue
+			}
+
+			idx := append(append([]int{}, index...), i)
+
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+
+			if sf.Anonymous && !tagged && ft.Kind() == reflect.Struct {
+				// a type that embeds itself would otherwise be walked indefinitely
+				if !path[ft] {
+					walk(ft, idx)
+				}
+				continue
+			}
+
+			if !sf.IsExported() {
+				continue
+			}
+
+			d, ok := depths[name]
+			switch {
+			case !ok:
+				depths[name] = len(idx)
+				fs = append(fs, field{name: name, index: idx})
+
+			case len(idx) < d:
+				depths[name] = len(idx)
+				for j := range fs {
+					if fs[j].name == name {
+						fs[j].index = idx
+					}
+				}
+			}
+		}
+	}
+	walk(t, nil)
+
+	fieldCache.Store(t, fs)
+	return fs
+}
+
+func fieldName(sf reflect.StructField) (string, bool) {
+	for _, key := range []string{"mexl", "json"} {
+		tag, ok := sf.Tag.Lookup(key)
+		if !ok {
+			continue
+		}
+
+		if name, _, _ := strings.Cut(tag, ","); name != "" {
+			return name, true
+		}
+	}
+
+	return sf.Name, false
+}
@@ -0,0 +1,261 @@
+package types_test
+
+import (
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stevecallear/mexl/types"
+)
+
+type (
+	testAddress struct {
+		City string `json:"city"`
+	}
+
+	testBase struct {
+		ID   int
+		Name string `mexl:"base_name"`
+	}
+
+	testUser struct {
+		testBase
+		Name     string            `mexl:"name" json:"full_name"`
+		Email    string            `json:"email,omitempty"`
+		Age      uint8             `json:"-"`
+		Address  *testAddress      `mexl:"address"`
+		Roles    []string          `mexl:"roles"`
+		Scores   [2]float64        `mexl:"scores"`
+		Attrs    map[string]int    `mexl:"attrs"`
+		IP       net.IP            `mexl:"ip"`
+		Level    testLevel         `mexl:"level"`
+		Any      any               `mexl:"any"`
+		Nested   map[string]any    `mexl:"nested"`
+		Children []*testAddress    `mexl:"children"`
+		Tags     map[string]string `mexl:"tags"`
+		private  string
+	}
+
+	testLevel int
+
+	testNode struct {
+		Name   string      `mexl:"name"`
+		Parent *testNode   `mexl:"parent"`
+		Kids   []*testNode `mexl:"kids"`
+	}
+
+	testSelf struct {
+		*testSelf
+		X int
+	}
+)
+
+func (l testLevel) String() string {
+	return [...]string{"low", "high"}[l]
+}
+
+func TestReflectObject(t *testing.T) {
+	user := testUser{
+		testBase: testBase{ID: 1, Name: "base"},
+		Name:     "name",
+		Email:    "a@b.com",
+		Age:      20,
+		Address:  &testAddress{City: "London"},
+		Roles:    []string{"admin"},
+		Scores:   [2]float64{1.5, 2},
+		Attrs:    map[string]int{"a": 1},
+		IP:       net.IPv4(127, 0, 0, 1),
+		Level:    1,
+		Nested:   map[string]any{"s": []int{1}},
+		private:  "private",
+	}
+
+	tests := []struct {
+		name  string
+		input any
+		exp   types.Object
+		err   bool
+	}{
+		{
+			name:  "nil",
+			input: nil,
+			exp:   &types.Null{},
+		},
+		{
+			name:  "scalar",
+			input: "a",
+			exp:   &types.String{Value: "a"},
+		},
+		{
+			name:  "named scalar",
+			input: testLevel(0),
+			exp:   &types.String{Value: "low"},
+		},
+		{
+			name:  "decimal",
+			input: []*big.Rat{decimal("0.1")},
+			exp:   types.Array{&types.Decimal{Value: decimal("0.1")}},
+		},
+		{
+			name:  "time",
+			input: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			exp:   &types.Time{Value: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:  "duration",
+			input: []time.Duration{time.Second},
+			exp:   types.Array{&types.Duration{Value: time.Second}},
+		},
+		{
+			name:  "typed slice",
+			input: []string{"a", "b"},
+			exp:   types.Array{&types.String{Value: "a"}, &types.String{Value: "b"}},
+		},
+		{
+			name:  "typed map",
+			input: map[string]int{"a": 1},
+			exp:   types.Map{"a": &types.Integer{Value: 1}},
+		},
+		{
+			name:  "nil pointer",
+			input: (*testAddress)(nil),
+			exp:   &types.Null{},
+		},
+		{
+			name:  "object",
+			input: &types.Integer{Value: 1},
+			exp:   &types.Integer{Value: 1},
+		},
+		{
+			name:  "invalid map key",
+			input: map[int]string{1: "a"},
+			err:   true,
+		},
+		{
+			name:  "invalid type",
+			input: make(chan int),
+			err:   true,
+		},
+		{
+			name:  "struct",
+			input: &user,
+			exp: types.Map{
+				"ID":        &types.Integer{Value: 1},
+				"base_name": &types.String{Value: "base"},
+				"name":      &types.String{Value: "name"},
+				"email":     &types.String{Value: "a@b.com"},
+				"address": types.Map{
+					"city": &types.String{Value: "London"},
+				},
+				"roles":  types.Array{&types.String{Value: "admin"}},
+				"scores": types.Array{&types.Float{Value: 1.5}, &types.Float{Value: 2}},
+				"attrs":  types.Map{"a": &types.Integer{Value: 1}},
+				"ip":     &types.String{Value: "127.0.0.1"},
+				"level":  &types.String{Value: "high"},
+				"any":    &types.Null{},
+				"nested": types.Map{
+					"s": types.Array{&types.Integer{Value: 1}},
+				},
+				"children": &types.Null{},
+				"tags":     &types.Null{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, err := types.ReflectObject(tt.input)
+			if err != nil && !tt.err {
+				t.Fatalf("got %v, expected nil", err)
+			}
+			if err == nil && tt.err {
+				t.Fatal("got nil, expected error")
+			}
+			if !reflect.DeepEqual(act, tt.exp) {
+				t.Errorf("got %v, expected %v", act, tt.exp)
+			}
+		})
+	}
+}
+
+func TestReflectObject_Cycles(t *testing.T) {
+	parent := &testNode{Name: "parent"}
+	parent.Kids = []*testNode{{Name: "kid", Parent: parent}}
+
+	shared := &testAddress{City: "London"}
+
+	cyclic := map[string]any{}
+	cyclic["self"] = cyclic
+
+	deep := &testNode{}
+	for range 2000 {
+		deep = &testNode{Parent: deep}
+	}
+
+	tests := []struct {
+		name  string
+		input any
+		exp   types.Object
+		err   bool
+	}{
+		{
+			name:  "pointer cycle",
+			input: parent,
+			err:   true,
+		},
+		{
+			name:  "map cycle",
+			input: cyclic,
+			err:   true,
+		},
+		{
+			name:  "maximum depth",
+			input: deep,
+			err:   true,
+		},
+		{
+			name:  "shared pointer",
+			input: []*testAddress{shared, shared},
+			exp: types.Array{
+				types.Map{"city": &types.String{Value: "London"}},
+				types.Map{"city": &types.String{Value: "London"}},
+			},
+		},
+		{
+			name:  "self embedding",
+			input: testSelf{X: 1},
+			exp:   types.Map{"X": &types.Integer{Value: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, err := types.ReflectObject(tt.input)
+			if err != nil && !tt.err {
+				t.Fatalf("got %v, expected nil", err)
+			}
+			if err == nil && tt.err {
+				t.Fatal("got nil, expected error")
+			}
+			if !reflect.DeepEqual(act, tt.exp) {
+				t.Errorf("got %v, expected %v", act, tt.exp)
+			}
+		})
+	}
+}
+
+func TestReflectMap(t *testing.T) {
+	act, err := types.ReflectMap(map[string]any{
+		"roles": []string{"admin"},
+	})
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	exp := types.Map{"roles": types.Array{&types.String{Value: "admin"}}}
+	if !reflect.DeepEqual(act, exp) {
+		t.Errorf("got %v, expected %v", act, exp)
+	}
+}
@@ -0,0 +1,83 @@
+package types
+
+import "fmt"
+
+type (
+	// Resolver resolves named objects on demand
+	Resolver interface {
+		Get(name string) (Object, bool)
+	}
+
+	// LazyMap is a resolver that converts native values the first time they are resolved
+	LazyMap struct {
+		values  map[string]any
+		objects map[string]Object
+		convert func(any) (Object, error)
+		err     error
+	}
+)
+
+var (
+	_ Resolver = (Map)(nil)
+	_ Resolver = (*LazyMap)(nil)
+)
+
+// Get returns the named object
+func (m Map) Get(name string) (Object, bool) {
+	o, ok := m[name]
+	return o, ok
+}
+
+// NewLazyMap returns a resolver for the native map using the specified conversion func
+// If convert is nil, ToObject is used.
+func NewLazyMap(m map[string]any, convert func(any) (Object, error)) *LazyMap {
+	if convert == nil {
+		convert = ToObject
+	}
+
+	return &LazyMap{
+		values:  m,
+		convert: convert,
+	}
+}
+
+// Get converts and returns the named object
+// If conversion fails, the object is not resolved and the error is available from Err.
+func (m *LazyMap) Get(name string) (Object, bool) {
+	if o, ok := m.objects[name]; ok {
+		return o, true
+	}
+
+	v, ok := m.values[name]
+	if !ok {
+		return nil, false
+	}
+
+	o, err := m.convert(v)
+	if err != nil {
+		if m.err == nil {
+			m.err = fmt.Errorf("%s: %w", name, err)
+		}
+		return nil, false
+	}
+
+	if m.objects == nil {
+		m.objects = make(map[string]Object, len(m.values))
+	}
+	m.objects[name] = o
+
+	return o, true
+}
+
+// Reset resets the resolver to convert the specified native map
+// This allows the resolver to be reused, retaining any allocated capacity.
+func (m *LazyMap) Reset(values map[string]any) {
+	m.values = values
+	m.err = nil
+	clear(m.objects)
+}
+
+// Err returns the first conversion error
+func (m *LazyMap) Err() error {
+	return m.err
+}
@@ -0,0 +1,112 @@
+package types_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stevecallear/mexl/types"
+)
+
+func TestMap_Get(t *testing.T) {
+	sut := types.Map{"a": &types.Integer{Value: 1}}
+
+	act, ok := sut.Get("a")
+	if !ok || !reflect.DeepEqual(act, &types.Integer{Value: 1}) {
+		t.Errorf("got %v, %v, expected 1, true", act, ok)
+	}
+
+	if _, ok = sut.Get("b"); ok {
+		t.Error("got true, expected false")
+	}
+}
+
+func TestLazyMap_Get(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]any
+		convert func(any) (types.Object, error)
+		input   string
+		exp     types.Object
+		ok      bool
+		err     bool
+	}{
+		{
+			name:   "default conversion",
+			values: map[string]any{"a": 1},
+			input:  "a",
+			exp:    &types.Integer{Value: 1},
+			ok:     true,
+		},
+		{
+			name:    "custom conversion",
+			values:  map[string]any{"a": []string{"b"}},
+			convert: types.ReflectObject,
+			input:   "a",
+			exp:     types.Array{&types.String{Value: "b"}},
+			ok:      true,
+		},
+		{
+			name:   "missing",
+			values: map[string]any{"a": 1},
+			input:  "b",
+		},
+		{
+			name:   "nil map",
+			values: nil,
+			input:  "a",
+		},
+		{
+			name:   "conversion error",
+			values: map[string]any{"a": []string{"b"}},
+			input:  "a",
+			err:    true,
+		},
+		{
+			name:   "unresolved conversion error",
+			values: map[string]any{"a": 1, "b": []string{"b"}},
+			input:  "a",
+			exp:    &types.Integer{Value: 1},
+			ok:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sut := types.NewLazyMap(tt.values, tt.convert)
+
+			act, ok := sut.Get(tt.input)
+			if ok != tt.ok {
+				t.Errorf("got %v, expected %v", ok, tt.ok)
+			}
+			if !reflect.DeepEqual(act, tt.exp) {
+				t.Errorf("got %v, expected %v", act, tt.exp)
+			}
+
+			err := sut.Err()
+			if err != nil && !tt.err {
+				t.Errorf("got %v, expected nil", err)
+			}
+			if err == nil && tt.err {
+				t.Error("got nil, expected error")
+			}
+		})
+	}
+}
+
+func TestLazyMap_Get_Cached(t *testing.T) {
+	var n int
+	sut := types.NewLazyMap(map[string]any{"a": 1}, func(v any) (types.Object, error) {
+		n++
+		return types.ToObject(v)
+	})
+
+	a, _ := sut.Get("a")
+	b, _ := sut.Get("a")
+
+	if a != b {
+		t.Errorf("got %p, expected %p", b, a)
+	}
+	if n != 1 {
+		t.Errorf("got %d conversions, expected 1", n)
+	}
+}
@@ -0,0 +1,218 @@
+package types
+
+import (
+	"sort"
+	"strings"
+)
+
+type (
+	// Spec describes the static type of a value
+	Spec struct {
+		Type     Type
+		Elem     *Spec
+		Fields   map[string]*Spec
+		Params   []*Spec
+		Variadic bool
+		Result   *Spec
+		Union    []*Spec
+	}
+
+	// Schema maps environment identifiers to their static types
+	Schema map[string]*Spec
+)
+
+// TypeAny is a static type that accepts a value of any type
+const TypeAny Type = "ANY"
+
+var (
+	SpecAny      = &Spec{Type: TypeAny}
+	SpecNull     = &Spec{Type: TypeNull}
+	SpecInteger  = &Spec{Type: TypeInteger}
+	SpecFloat    = &Spec{Type: TypeFloat}
+	SpecDecimal  = &Spec{Type: TypeDecimal}
+	SpecString   = &Spec{Type: TypeString}
+	SpecBoolean  = &Spec{Type: TypeBoolean}
+	SpecTime     = &Spec{Type: TypeTime}
+	SpecDuration = &Spec{Type: TypeDuration}
+)
+
+// ArrayOf returns an array spec with the specified element type
+func ArrayOf(elem *Spec) *Spec {
+	return &Spec{Type: TypeArray, Elem: elem}
+}
+
+// MapOf returns a map spec with the specified fields
+// A nil fields map allows any member
+func MapOf(fields map[string]*Spec) *Spec {
+	return &Spec{Type: TypeMap, Fields: fields}
+}
+
+// FuncOf returns a function spec with the specified result and parameter types
+func FuncOf(result *Spec, params ...*Spec) *Spec {
+	return &Spec{Type: TypeFunc, Params: params, Result: result}
+}
+
+// VariadicFuncOf returns a function spec where the final parameter can be repeated
+func VariadicFuncOf(result *Spec, params ...*Spec) *Spec {
+	return &Spec{Type: TypeFunc, Params: params, Variadic: true, Result: result}
+}
+
+// OneOf returns a spec that accepts any of the specified types
+func OneOf(specs ...*Spec) *Spec {
+	return &Spec{Type: TypeAny, Union: specs}
+}
+
+// Accepts returns true if a value of spec o can be used where s is expected
+// Null values are accepted for any type, as they are coerced at runtime
+func (s *Spec) Accepts(o *Spec) bool {
+	if s.Union != nil {
+		for _, u := range s.Union {
+			if u.Accepts(o) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if o.Union != nil {
+		for _, u := range o.Union {
+			if !s.Accepts(u) {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch {
+	case s.Type == TypeAny || o.Type == TypeAny || o.Type == TypeNull:
+		return true
+
+	case s.Type == TypeFloat && o.Type == TypeInteger:
+		return true
+
+	case s.Type == TypeDecimal && (o.Type == TypeInteger || o.Type == TypeFloat):
+		return true
+
+	case s.Type != o.Type:
+		return false
+
+	case s.Type == TypeArray:
+		return s.Elem == nil || o.Elem == nil || s.Elem.Accepts(o.Elem)
+
+	case s.Type == TypeMap:
+		for k, f := range s.Fields {
+			if of, ok := o.Fields[k]; ok && !f.Accepts(of) {
+				return false
+			}
+		}
+		return true
+
+	default:
+		return true
+	}
+}
+
+// Matches returns true if the runtime value o is of spec s
+// Null values match any type, consistent with Accepts
+func (s *Spec) Matches(o Object) bool {
+	if s.Union != nil {
+		for _, u := range s.Union {
+			if u.Matches(o) {
+				return true
+			}
+		}
+		return false
+	}
+
+	t := o.Type()
+
+	switch {
+	case s.Type == TypeAny || t == TypeNull:
+		return true
+
+	case s.Type == TypeFloat && t == TypeInteger:
+		return true
+
+	case s.Type == TypeDecimal && (t == TypeInteger || t == TypeFloat):
+		return true
+
+	case s.Type != t:
+		return false
+
+	case s.Type == TypeArray && s.Elem != nil:
+		for _, e := range o.(Array) {
+			if !s.Elem.Matches(e) {
+				return false
+			}
+		}
+		return true
+
+	case s.Type == TypeMap:
+		m := o.(Map)
+		for k, f := range s.Fields {
+			if v, ok := m[k]; ok && !f.Matches(v) {
+				return false
+			}
+		}
+		return true
+
+	default:
+		return true
+	}
+}
+
+// Member returns the spec of the named member
+func (s *Spec) Member(name string) (*Spec, bool) {
+	switch {
+	case s.Type == TypeAny, s.Type == TypeMap && s.Fields == nil:
+		return SpecAny, true
+
+	case s.Type == TypeNull:
+		return SpecNull, true
+
+	default:
+		f, ok := s.Fields[name]
+		return f, ok
+	}
+}
+
+// String returns a string representation of the spec
+func (s *Spec) String() string {
+	switch {
+	case s.Union != nil:
+		us := make([]string, len(s.Union))
+		for i, u := range s.Union {
+			us[i] = u.String()
+		}
+		return strings.Join(us, "|")
+
+	case s.Type == TypeArray && s.Elem != nil:
+		return "ARRAY<" + s.Elem.String() + ">"
+
+	case s.Type == TypeMap && s.Fields != nil:
+		ks := make([]string, 0, len(s.Fields))
+		for k := range s.Fields {
+			ks = append(ks, k)
+		}
+		sort.Strings(ks)
+
+		fs := make([]string, len(ks))
+		for i, k := range ks {
+			fs[i] = k + ": " + s.Fields[k].String()
+		}
+		return "MAP{" + strings.Join(fs, ", ") + "}"
+
+	case s.Type == TypeFunc && s.Result != nil:
+		ps := make([]string, len(s.Params))
+		for i, p := range s.Params {
+			ps[i] = p.String()
+		}
+		if s.Variadic && len(ps) > 0 {
+			ps[len(ps)-1] = "..." + ps[len(ps)-1]
+		}
+		return "FUNC(" + strings.Join(ps, ", ") + ") " + s.Result.String()
+
+	default:
+		return string(s.Type)
+	}
+}
@@ -0,0 +1,132 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/stevecallear/mexl/types"
+)
+
+func TestSpec_Accepts(t *testing.T) {
+	tests := []struct {
+		name string
+		sut  *types.Spec
+		arg  *types.Spec
+		exp  bool
+	}{
+		{"same type", types.SpecString, types.SpecString, true},
+		{"different type", types.SpecString, types.SpecInteger, false},
+		{"any", types.SpecAny, types.SpecInteger, true},
+		{"any arg", types.SpecInteger, types.SpecAny, true},
+		{"null arg", types.SpecInteger, types.SpecNull, true},
+		{"integer to float", types.SpecFloat, types.SpecInteger, true},
+		{"float to integer", types.SpecInteger, types.SpecFloat, false},
+		{"array", types.ArrayOf(types.SpecFloat), types.ArrayOf(types.SpecInteger), true},
+		{"array elem mismatch", types.ArrayOf(types.SpecString), types.ArrayOf(types.SpecInteger), false},
+		{"open map", types.MapOf(nil), types.MapOf(map[string]*types.Spec{"a": types.SpecString}), true},
+		{
+			name: "map field mismatch",
+			sut:  types.MapOf(map[string]*types.Spec{"a": types.SpecString}),
+			arg:  types.MapOf(map[string]*types.Spec{"a": types.SpecInteger}),
+			exp:  false,
+		},
+		{"union", types.OneOf(types.SpecString, types.SpecInteger), types.SpecInteger, true},
+		{"union mismatch", types.OneOf(types.SpecString, types.SpecInteger), types.SpecBoolean, false},
+		{"union arg", types.SpecFloat, types.OneOf(types.SpecInteger, types.SpecFloat), true},
+		{"union arg mismatch", types.SpecInteger, types.OneOf(types.SpecInteger, types.SpecFloat), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if act := tt.sut.Accepts(tt.arg); act != tt.exp {
+				t.Errorf("got %v, expected %v", act, tt.exp)
+			}
+		})
+	}
+}
+
+func TestSpec_Matches(t *testing.T) {
+	user := types.MapOf(map[string]*types.Spec{"name": types.SpecString})
+
+	tests := []struct {
+		name string
+		sut  *types.Spec
+		obj  types.Object
+		exp  bool
+	}{
+		{"same type", types.SpecString, &types.String{Value: "a"}, true},
+		{"different type", types.SpecString, &types.Integer{Value: 1}, false},
+		{"null", types.SpecString, &types.Null{}, true},
+		{"any", types.SpecAny, &types.Integer{Value: 1}, true},
+		{"integer as float", types.SpecFloat, &types.Integer{Value: 1}, true},
+		{"float as integer", types.SpecInteger, &types.Float{Value: 1}, false},
+		{"array elem", types.ArrayOf(types.SpecString), types.Array{&types.String{Value: "a"}}, true},
+		{"array elem mismatch", types.ArrayOf(types.SpecString), types.Array{&types.Integer{Value: 1}}, false},
+		{"map field", user, types.Map{"name": &types.String{Value: "a"}}, true},
+		{"map missing field", user, types.Map{}, true},
+		{"map field mismatch", user, types.Map{"name": &types.Integer{Value: 1}}, false},
+		{"union", types.OneOf(types.SpecString, types.SpecInteger), &types.Integer{Value: 1}, true},
+		{"union mismatch", types.OneOf(types.SpecString, types.SpecInteger), &types.Boolean{Value: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if act := tt.sut.Matches(tt.obj); act != tt.exp {
+				t.Errorf("got %v, expected %v", act, tt.exp)
+			}
+		})
+	}
+}
+
+func TestSpec_Member(t *testing.T) {
+	sut := types.MapOf(map[string]*types.Spec{"a": types.SpecString})
+
+	tests := []struct {
+		name   string
+		sut    *types.Spec
+		member string
+		exp    *types.Spec
+		ok     bool
+	}{
+		{"declared", sut, "a", types.SpecString, true},
+		{"undeclared", sut, "b", nil, false},
+		{"open map", types.MapOf(nil), "b", types.SpecAny, true},
+		{"any", types.SpecAny, "b", types.SpecAny, true},
+		{"null", types.SpecNull, "b", types.SpecNull, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, ok := tt.sut.Member(tt.member)
+			if ok != tt.ok {
+				t.Errorf("got %v, expected %v", ok, tt.ok)
+			}
+			if act != tt.exp {
+				t.Errorf("got %v, expected %v", act, tt.exp)
+			}
+		})
+	}
+}
+
+func TestSpec_String(t *testing.T) {
+	tests := []struct {
+		sut *types.Spec
+		exp string
+	}{
+		{types.SpecInteger, "INTEGER"},
+		{types.ArrayOf(nil), "ARRAY"},
+		{types.ArrayOf(types.SpecString), "ARRAY<STRING>"},
+		{types.MapOf(nil), "MAP"},
+		{types.MapOf(map[string]*types.Spec{"b": types.SpecString, "a": types.SpecAny}), "MAP{a: ANY, b: STRING}"},
+		{types.FuncOf(types.SpecString, types.SpecInteger), "FUNC(INTEGER) STRING"},
+		{types.VariadicFuncOf(types.SpecString, types.SpecString, types.SpecAny), "FUNC(STRING, ...ANY) STRING"},
+		{types.OneOf(types.SpecString, types.SpecNull), "STRING|NULL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.exp, func(t *testing.T) {
+			if act := tt.sut.String(); act != tt.exp {
+				t.Errorf("got %s, expected %s", act, tt.exp)
+			}
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package types
 
 import (
+	"context"
 	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type (
@@ -23,6 +26,12 @@ type (
 		Value float64
 	}
 
+	// Decimal represents an exact decimal number
+	// The value must not be modified once the decimal has been created.
+	Decimal struct {
+		Value *big.Rat
+	}
+
 	String struct {
 		Value string
 	}
@@ -31,34 +40,56 @@ type (
 		Value bool
 	}
 
-	Null struct{}
+	Null struct {
+		missing bool
+	}
+
+	Time struct {
+		Value time.Time
+	}
+
+	Duration struct {
+		Value time.Duration
+	}
 
 	Array []Object
 
 	Map map[string]Object
 
+	// Func represents a function
 	Func func(args ...Object) (Object, error)
+
+	// ContextFunc represents a function that receives the context of the current run
+	ContextFunc func(ctx context.Context, args ...Object) (Object, error)
 )
 
 const (
-	TypeNull    Type = "NULL"
-	TypeInteger Type = "INTEGER"
-	TypeFloat   Type = "FLOAT"
-	TypeString  Type = "STRING"
-	TypeBoolean Type = "BOOLEAN"
-	TypeArray   Type = "ARRAY"
-	TypeMap     Type = "MAP"
-	TypeFunc    Type = "FUNC"
+	TypeNull     Type = "NULL"
+	TypeInteger  Type = "INTEGER"
+	TypeFloat    Type = "FLOAT"
+	TypeDecimal  Type = "DECIMAL"
+	TypeString   Type = "STRING"
+	TypeBoolean  Type = "BOOLEAN"
+	TypeArray    Type = "ARRAY"
+	TypeMap      Type = "MAP"
+	TypeFunc     Type = "FUNC"
+	TypeTime     Type = "TIME"
+	TypeDuration Type = "DURATION"
 )
 
 var (
 	_ Object = (*Integer)(nil)
 	_ Object = (*Float)(nil)
+	_ Object = (*Decimal)(nil)
 	_ Object = (*String)(nil)
 	_ Object = (*Boolean)(nil)
 	_ Object = (Array)(nil)
 	_ Object = (*Null)(nil)
+	_ Object = (*Time)(nil)
+	_ Object = (*Duration)(nil)
 	_ Object = (Map)(nil)
+	_ Object = (Func)(nil)
+	_ Object = (ContextFunc)(nil)
 )
 
 func (i *Integer) Equal(o Object) bool {
@@ -95,6 +126,23 @@ func (f *Float) Inspect() string {
 	return strconv.FormatFloat(f.Value, 'f', -1, 64)
 }
 
+func (d *Decimal) Equal(o Object) bool {
+	if d == o {
+		return true
+	}
+
+	t, ok := o.(*Decimal)
+	return ok && d.Value.Cmp(t.Value) == 0
+}
+
+func (d *Decimal) Type() Type {
+	return TypeDecimal
+}
+
+func (d *Decimal) Inspect() string {
+	return FormatDecimal(d.Value)
+}
+
 func (s *String) Type() Type {
 	return TypeString
 }
@@ -130,6 +178,48 @@ func (b *Boolean) Inspect() string {
 	return strconv.FormatBool(b.Value)
 }
 
+func (t *Time) Equal(o Object) bool {
+	if t == o {
+		return true
+	}
+
+	ot, ok := o.(*Time)
+	return ok && t.Value.Equal(ot.Value)
+}
+
+func (t *Time) Type() Type {
+	return TypeTime
+}
+
+func (t *Time) Inspect() string {
+	return t.Value.Format(time.RFC3339Nano)
+}
+
+func (d *Duration) Equal(o Object) bool {
+	if d == o {
+		return true
+	}
+
+	od, ok := o.(*Duration)
+	return ok && d.Value == od.Value
+}
+
+func (d *Duration) Type() Type {
+	return TypeDuration
+}
+
+func (d *Duration) Inspect() string {
+	return d.Value.String()
+}
+
+// Missing is the null value of an identifier, member or element that does not exist
+var Missing = &Null{missing: true}
+
+// IsMissing returns true if the null value represents an identifier, member or element that does not exist
+func (n *Null) IsMissing() bool {
+	return n.missing
+}
+
 func (n *Null) Equal(o Object) bool {
 	if n == o {
 		return true
@@ -220,3 +310,16 @@ func (f Func) Equal(o Object) bool {
 func (f Func) Inspect() string {
 	return "func"
 }
+
+func (f ContextFunc) Type() Type {
+	return TypeFunc
+}
+
+func (f ContextFunc) Equal(o Object) bool {
+	// func types are not comparable
+	return false
+}
+
+func (f ContextFunc) Inspect() string {
+	return "func"
+}
@@ -3,6 +3,7 @@ package types_test
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stevecallear/mexl/types"
 )
@@ -312,6 +313,206 @@ func TestNull_Inspect(t *testing.T) {
 	}
 }
 
+func TestTime_Type(t *testing.T) {
+	exp := types.TypeTime
+	act := new(types.Time).Type()
+
+	if act != exp {
+		t.Errorf("got %s, expected %s", act, exp)
+	}
+}
+
+func TestTime_Equal(t *testing.T) {
+	sut := &types.Time{Value: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name string
+		cmp  types.Object
+		exp  bool
+	}{
+		{
+			name: "not equal (type)",
+			cmp:  &types.Integer{},
+			exp:  false,
+		},
+		{
+			name: "not equal (value)",
+			cmp:  &types.Time{},
+			exp:  false,
+		},
+		{
+			name: "equal (pointer)",
+			cmp:  sut,
+			exp:  true,
+		},
+		{
+			name: "equal (location)",
+			cmp:  &types.Time{Value: sut.Value.In(time.FixedZone("UTC+1", 3600))},
+			exp:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := sut.Equal(tt.cmp)
+			if act != tt.exp {
+				t.Errorf("got %v, expected %v", act, tt.exp)
+			}
+		})
+	}
+}
+
+func TestTime_Inspect(t *testing.T) {
+	sut := &types.Time{Value: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)}
+	exp := "2024-01-02T10:00:00Z"
+	act := sut.Inspect()
+
+	if act != exp {
+		t.Errorf("got %s, expected %s", act, exp)
+	}
+}
+
+func TestDuration_Type(t *testing.T) {
+	exp := types.TypeDuration
+	act := new(types.Duration).Type()
+
+	if act != exp {
+		t.Errorf("got %s, expected %s", act, exp)
+	}
+}
+
+func TestDuration_Equal(t *testing.T) {
+	sut := &types.Duration{Value: time.Minute}
+
+	tests := []struct {
+		name string
+		cmp  types.Object
+		exp  bool
+	}{
+		{
+			name: "not equal (type)",
+			cmp:  &types.Integer{Value: int64(time.Minute)},
+			exp:  false,
+		},
+		{
+			name: "not equal (value)",
+			cmp:  &types.Duration{Value: time.Second},
+			exp:  false,
+		},
+		{
+			name: "equal (pointer)",
+			cmp:  sut,
+			exp:  true,
+		},
+		{
+			name: "equal (value)",
+			cmp:  &types.Duration{Value: time.Minute},
+			exp:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := sut.Equal(tt.cmp)
+			if act != tt.exp {
+				t.Errorf("got %v, expected %v", act, tt.exp)
+			}
+		})
+	}
+}
+
+func TestDuration_Inspect(t *testing.T) {
+	sut := &types.Duration{Value: 90 * time.Minute}
+	exp := "1h30m0s"
+	act := sut.Inspect()
+
+	if act != exp {
+		t.Errorf("got %s, expected %s", act, exp)
+	}
+}
+
+func TestDecimal_Type(t *testing.T) {
+	exp := types.TypeDecimal
+	act := new(types.Decimal).Type()
+
+	if act != exp {
+		t.Errorf("got %s, expected %s", act, exp)
+	}
+}
+
+func TestDecimal_Equal(t *testing.T) {
+	sut := &types.Decimal{Value: decimal("1.10")}
+
+	tests := []struct {
+		name string
+		cmp  types.Object
+		exp  bool
+	}{
+		{
+			name: "not equal (type)",
+			cmp:  &types.Float{Value: 1.1},
+			exp:  false,
+		},
+		{
+			name: "not equal (value)",
+			cmp:  &types.Decimal{Value: decimal("1.11")},
+			exp:  false,
+		},
+		{
+			name: "equal (pointer)",
+			cmp:  sut,
+			exp:  true,
+		},
+		{
+			name: "equal (scale)",
+			cmp:  &types.Decimal{Value: decimal("1.1")},
+			exp:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := sut.Equal(tt.cmp)
+			if act != tt.exp {
+				t.Errorf("got %v, expected %v", act, tt.exp)
+			}
+		})
+	}
+}
+
+func TestDecimal_Inspect(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		exp   string
+	}{
+		{
+			name:  "integer",
+			input: "2.00",
+			exp:   "2",
+		},
+		{
+			name:  "exact",
+			input: "-0.125",
+			exp:   "-0.125",
+		},
+		{
+			name:  "recurring",
+			input: "1/3",
+			exp:   "0.3333333333333333",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := (&types.Decimal{Value: decimal(tt.input)}).Inspect()
+			if act != tt.exp {
+				t.Errorf("got %s, expected %s", act, tt.exp)
+			}
+		})
+	}
+}
+
 func TestArray_Type(t *testing.T) {
 	exp := types.TypeArray
 	act := types.Array{}.Type()
@@ -27,7 +27,25 @@ var builtIns = map[string]types.Func{
 			return nil, fmt.Errorf("invalid argument: %T", args[0])
 		}
 
-		return &types.Integer{Value: int64(l)}, nil
+		return types.NewInteger(int64(l)), nil
+	},
+
+	"coalesce": func(args ...types.Object) (types.Object, error) {
+		for _, a := range args {
+			if a.Type() != types.TypeNull {
+				return a, nil
+			}
+		}
+		return objNull, nil
+	},
+
+	"defined": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("defined", args, 1); err != nil {
+			return nil, err
+		}
+
+		n, ok := args[0].(*types.Null)
+		return boolToObject(!ok || !n.IsMissing()), nil
 	},
 
 	"lower": func(args ...types.Object) (types.Object, error) {
@@ -40,15 +58,29 @@ var builtIns = map[string]types.Func{
 			return objNull, nil
 
 		case types.TypeString:
-			return &types.String{
-				Value: strings.ToLower(args[0].(*types.String).Value),
-			}, nil
+			return types.NewString(strings.ToLower(args[0].(*types.String).Value)), nil
 
 		default:
 			return nil, fmt.Errorf("lower: wrong arg type: %s, expected %s", args[0].Type(), types.TypeString)
 		}
 	},
 
+	"regexMatch": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("regexMatch", args, 2); err != nil {
+			return nil, err
+		}
+
+		return regexMatch("regexMatch", args[0], args[1])
+	},
+
+	"regexFind": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("regexFind", args, 2); err != nil {
+			return nil, err
+		}
+
+		return regexFind("regexFind", args[0], args[1])
+	},
+
 	"upper": func(args ...types.Object) (types.Object, error) {
 		if err := expectArgsLen("upper", args, 1); err != nil {
 			return nil, err
@@ -59,9 +91,7 @@ var builtIns = map[string]types.Func{
 			return objNull, nil
 
 		case types.TypeString:
-			return &types.String{
-				Value: strings.ToUpper(args[0].(*types.String).Value),
-			}, nil
+			return types.NewString(strings.ToUpper(args[0].(*types.String).Value)), nil
 
 		default:
 			return nil, fmt.Errorf("upper: wrong arg type: %s, expected %s", args[0].Type(), types.TypeString)
@@ -69,9 +99,30 @@ var builtIns = map[string]types.Func{
 	},
 }
 
+// IsBuiltIn returns true if the named function is built in
+func IsBuiltIn(name string) bool {
+	_, ok := builtIns[name]
+	return ok
+}
+
 func expectArgsLen(name string, args []types.Object, l int) error {
 	if len(args) != l {
 		return fmt.Errorf("%s: wrong number of arguments: %d, expected %d", name, len(args), l)
 	}
 	return nil
 }
+
+// expectArgsRange returns an error if the number of arguments is outside the range
+// A negative maximum indicates that the function is variadic.
+func expectArgsRange(name string, args []types.Object, minLen, maxLen int) error {
+	switch {
+	case maxLen < 0 && len(args) < minLen:
+		return fmt.Errorf("%s: wrong number of arguments: %d, expected at least %d", name, len(args), minLen)
+
+	case maxLen >= 0 && (len(args) < minLen || len(args) > maxLen):
+		return fmt.Errorf("%s: wrong number of arguments: %d, expected %d to %d", name, len(args), minLen, maxLen)
+
+	default:
+		return nil
+	}
+}
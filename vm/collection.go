@@ -0,0 +1,270 @@
+package vm
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/stevecallear/mexl/types"
+)
+
+var collectionBuiltIns = map[string]types.Func{
+	"keys": func(args ...types.Object) (types.Object, error) {
+		return mapFunc("keys", args, func(m types.Map) types.Object {
+			ks := sortedKeys(m)
+
+			a := make(types.Array, len(ks))
+			for i, k := range ks {
+				a[i] = types.NewString(k)
+			}
+			return a
+		})
+	},
+
+	"values": func(args ...types.Object) (types.Object, error) {
+		return mapFunc("values", args, func(m types.Map) types.Object {
+			ks := sortedKeys(m)
+
+			a := make(types.Array, len(ks))
+			for i, k := range ks {
+				a[i] = m[k]
+			}
+			return a
+		})
+	},
+
+	"has": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("has", args, 2); err != nil {
+			return nil, err
+		}
+
+		k, err := stringArg("has", args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		switch m := args[0].(type) {
+		case *types.Null:
+			return objFalse, nil
+
+		case types.Resolver:
+			_, ok := m.Get(k)
+			return boolToObject(ok), nil
+
+		default:
+			return nil, fmt.Errorf("has: wrong arg type: %s, expected %s", args[0].Type(), types.TypeMap)
+		}
+	},
+
+	"first": func(args ...types.Object) (types.Object, error) {
+		return arrayFunc("first", args, func(a types.Array) types.Object {
+			if len(a) < 1 {
+				return objNull
+			}
+			return a[0]
+		})
+	},
+
+	"last": func(args ...types.Object) (types.Object, error) {
+		return arrayFunc("last", args, func(a types.Array) types.Object {
+			if len(a) < 1 {
+				return objNull
+			}
+			return a[len(a)-1]
+		})
+	},
+
+	"sort": func(args ...types.Object) (types.Object, error) {
+		return arrayFunc("sort", args, func(a types.Array) types.Object {
+			c := slices.Clone(a)
+			slices.SortStableFunc(c, types.Compare)
+			return c
+		})
+	},
+
+	"reverse": func(args ...types.Object) (types.Object, error) {
+		return arrayFunc("reverse", args, func(a types.Array) types.Object {
+			c := slices.Clone(a)
+			slices.Reverse(c)
+			return c
+		})
+	},
+
+	"unique": func(args ...types.Object) (types.Object, error) {
+		return arrayFunc("unique", args, func(a types.Array) types.Object {
+			return appendUnique(types.Array{}, a...)
+		})
+	},
+
+	"flatten": func(args ...types.Object) (types.Object, error) {
+		return arrayFunc("flatten", args, func(a types.Array) types.Object {
+			r := make(types.Array, 0, len(a))
+			for _, e := range a {
+				if ea, ok := e.(types.Array); ok {
+					r = append(r, ea...)
+				} else {
+					r = append(r, e)
+				}
+			}
+			return r
+		})
+	},
+
+	"concat": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsRange("concat", args, 1, -1); err != nil {
+			return nil, err
+		}
+
+		r := types.Array{}
+		for _, o := range args {
+			a, err := arrayArg("concat", o)
+			if err != nil {
+				return nil, err
+			}
+			r = append(r, a...)
+		}
+
+		return r, nil
+	},
+
+	"contains": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("contains", args, 2); err != nil {
+			return nil, err
+		}
+
+		switch v := args[0].(type) {
+		case *types.Null:
+			return objFalse, nil
+
+		case *types.String:
+			s, err := stringArg("contains", args[1])
+			if err != nil {
+				return nil, err
+			}
+			return boolToObject(strings.Contains(v.Value, s)), nil
+
+		case types.Array:
+			return boolToObject(containsObject(v, args[1])), nil
+
+		default:
+			return nil, fmt.Errorf("contains: wrong arg type: %s, expected %s", args[0].Type(), types.TypeArray)
+		}
+	},
+
+	"intersect": func(args ...types.Object) (types.Object, error) {
+		a, b, err := arrayPair("intersect", args)
+		if err != nil {
+			return nil, err
+		}
+
+		r := types.Array{}
+		for _, e := range a {
+			if containsObject(b, e) && !containsObject(r, e) {
+				r = append(r, e)
+			}
+		}
+
+		return r, nil
+	},
+
+	"union": func(args ...types.Object) (types.Object, error) {
+		a, b, err := arrayPair("union", args)
+		if err != nil {
+			return nil, err
+		}
+
+		return appendUnique(appendUnique(types.Array{}, a...), b...), nil
+	},
+}
+
+func init() {
+	for k, v := range collectionBuiltIns {
+		builtIns[k] = v
+	}
+}
+
+// arrayFunc applies the function to the single array argument, returning null if the argument is null
+func arrayFunc(name string, args []types.Object, fn func(types.Array) types.Object) (types.Object, error) {
+	if err := expectArgsLen(name, args, 1); err != nil {
+		return nil, err
+	}
+
+	a, err := arrayArg(name, args[0])
+	if err != nil || args[0].Type() == types.TypeNull {
+		return objNull, err
+	}
+
+	return fn(a), nil
+}
+
+// mapFunc applies the function to the single map argument, returning null if the argument is null
+func mapFunc(name string, args []types.Object, fn func(types.Map) types.Object) (types.Object, error) {
+	if err := expectArgsLen(name, args, 1); err != nil {
+		return nil, err
+	}
+
+	switch m := args[0].(type) {
+	case *types.Null:
+		return objNull, nil
+	case types.Map:
+		return fn(m), nil
+	default:
+		return nil, fmt.Errorf("%s: wrong arg type: %s, expected %s", name, args[0].Type(), types.TypeMap)
+	}
+}
+
+// arrayPair returns the two array arguments, coercing nulls to empty arrays
+func arrayPair(name string, args []types.Object) (types.Array, types.Array, error) {
+	if err := expectArgsLen(name, args, 2); err != nil {
+		return nil, nil, err
+	}
+
+	a, err := arrayArg(name, args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, err := arrayArg(name, args[1])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return a, b, nil
+}
+
+// arrayArg returns the array value of the object, coercing null to an empty array
+func arrayArg(name string, o types.Object) (types.Array, error) {
+	switch v := o.(type) {
+	case types.Array:
+		return v, nil
+	case *types.Null:
+		return types.Array{}, nil
+	default:
+		return nil, fmt.Errorf("%s: wrong arg type: %s, expected %s", name, o.Type(), types.TypeArray)
+	}
+}
+
+// containsObject returns true if the array contains an equal object, matching the in operator
+func containsObject(a types.Array, o types.Object) bool {
+	return slices.ContainsFunc(a, o.Equal)
+}
+
+// appendUnique appends the objects that are not already present in the array
+// Arrays are expected to be small, so equality is checked using Equal rather than hashing.
+func appendUnique(a types.Array, objs ...types.Object) types.Array {
+	for _, o := range objs {
+		if !containsObject(a, o) {
+			a = append(a, o)
+		}
+	}
+	return a
+}
+
+func sortedKeys(m types.Map) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	slices.Sort(ks)
+	return ks
+}
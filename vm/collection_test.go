@@ -0,0 +1,143 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/stevecallear/mexl/types"
+)
+
+func TestCollectionBuiltIns(t *testing.T) {
+	env := types.Map{
+		"m": types.Map{
+			"b": &types.Integer{Value: 2},
+			"a": &types.Integer{Value: 1},
+		},
+		"tags": types.Array{
+			&types.String{Value: "b"},
+			&types.String{Value: "a"},
+			&types.String{Value: "b"},
+		},
+	}
+
+	tests := []testCase{
+		{
+			name: "keys",
+			prog: compile("keys(m)"),
+			env:  env,
+			exp:  []any{"a", "b"},
+		},
+		{
+			name: "keys null",
+			prog: compile("keys(x)"),
+			exp:  nil,
+		},
+		{
+			name: "keys arg type error",
+			prog: compile("keys([1])"),
+			err:  true,
+		},
+		{
+			name: "values",
+			prog: compile("values(m)"),
+			env:  env,
+			exp:  []any{1, 2},
+		},
+		{
+			name: "has",
+			prog: compile(`[has(m, "a"), has(m, "c"), has(x, "a")]`),
+			env:  env,
+			exp:  []any{true, false, false},
+		},
+		{
+			name: "has arg type error",
+			prog: compile(`has(m, 1)`),
+			env:  env,
+			err:  true,
+		},
+		{
+			name: "first",
+			prog: compile("[first(tags), first([]), first(x)]"),
+			env:  env,
+			exp:  []any{"b", nil, nil},
+		},
+		{
+			name: "last",
+			prog: compile("[last([1, 2]), last([])]"),
+			exp:  []any{2, nil},
+		},
+		{
+			name: "sort",
+			prog: compile(`sort([3, "a", 1.5, null, true, 2, [1]])`),
+			exp:  []any{nil, true, 1.5, 2, 3, "a", []any{1}},
+		},
+		{
+			name: "sort does not modify",
+			prog: compile("sort(tags) eq tags"),
+			env:  env,
+			exp:  false,
+		},
+		{
+			name: "sort arg type error",
+			prog: compile(`sort("a")`),
+			err:  true,
+		},
+		{
+			name: "reverse",
+			prog: compile("[reverse([1, 2, 3]), reverse(x)]"),
+			exp:  []any{[]any{3, 2, 1}, nil},
+		},
+		{
+			name: "unique",
+			prog: compile(`unique([1, 2, 1, "a", "a", [1], [1]])`),
+			exp:  []any{1, 2, "a", []any{1}},
+		},
+		{
+			name: "flatten",
+			prog: compile("flatten([[1, 2], 3, [[4]]])"),
+			exp:  []any{1, 2, 3, []any{4}},
+		},
+		{
+			name: "concat",
+			prog: compile("concat([1], x, [2, 3])"),
+			exp:  []any{1, 2, 3},
+		},
+		{
+			name: "concat args count error",
+			prog: compile("concat()"),
+			err:  true,
+		},
+		{
+			name: "concat arg type error",
+			prog: compile("concat([1], 2)"),
+			err:  true,
+		},
+		{
+			name: "contains",
+			prog: compile(`[contains(tags, "a"), contains([1, 2], 3), contains("abc", "b"), contains(x, 1)]`),
+			env:  env,
+			exp:  []any{true, false, true, false},
+		},
+		{
+			name: "contains arg type error",
+			prog: compile("contains(1, 1)"),
+			err:  true,
+		},
+		{
+			name: "intersect",
+			prog: compile("[intersect([1, 2, 2, 3], [3, 2, 4]), intersect(x, [1])]"),
+			exp:  []any{[]any{2, 3}, []any{}},
+		},
+		{
+			name: "union",
+			prog: compile("[union([1, 2, 2], [3, 1]), union(x, [1])]"),
+			exp:  []any{[]any{1, 2, 3}, []any{1}},
+		},
+		{
+			name: "union arg type error",
+			prog: compile(`union([1], "a")`),
+			err:  true,
+		},
+	}
+
+	testVM(t, tests)
+}
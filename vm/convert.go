@@ -0,0 +1,177 @@
+package vm
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/stevecallear/mexl/types"
+)
+
+var convertBuiltIns = map[string]types.Func{
+	"string": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("string", args, 1); err != nil {
+			return nil, err
+		}
+
+		switch a := args[0].(type) {
+		case *types.Null:
+			return objNull, nil
+
+		case *types.String:
+			return a, nil
+
+		case types.Func, types.ContextFunc:
+			return nil, convertError("string", a, types.TypeString)
+
+		default:
+			return types.NewString(a.Inspect()), nil
+		}
+	},
+
+	"int": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("int", args, 1); err != nil {
+			return nil, err
+		}
+
+		switch a := args[0].(type) {
+		case *types.Null:
+			return objNull, nil
+
+		case *types.Integer:
+			return a, nil
+
+		case *types.Float:
+			// the value is truncated towards zero
+			if !math.IsNaN(a.Value) && a.Value >= math.MinInt64 && a.Value < math.MaxInt64 {
+				return types.NewInteger(int64(a.Value)), nil
+			}
+
+		case *types.Decimal:
+			v := new(big.Int).Quo(a.Value.Num(), a.Value.Denom())
+			if v.IsInt64() {
+				return types.NewInteger(v.Int64()), nil
+			}
+
+		case *types.String:
+			if v, err := strconv.ParseInt(a.Value, 10, 64); err == nil {
+				return types.NewInteger(v), nil
+			}
+
+		case *types.Boolean:
+			if a.Value {
+				return types.NewInteger(1), nil
+			}
+			return types.NewInteger(0), nil
+		}
+
+		return nil, convertError("int", args[0], types.TypeInteger)
+	},
+
+	"float": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("float", args, 1); err != nil {
+			return nil, err
+		}
+
+		switch a := args[0].(type) {
+		case *types.Null:
+			return objNull, nil
+
+		case *types.Integer, *types.Float, *types.Decimal:
+			o, _ := types.Convert(a, types.TypeFloat)
+			return o, nil
+
+		case *types.String:
+			if v, err := strconv.ParseFloat(a.Value, 64); err == nil {
+				return &types.Float{Value: v}, nil
+			}
+
+		case *types.Boolean:
+			if a.Value {
+				return &types.Float{Value: 1}, nil
+			}
+			return &types.Float{Value: 0}, nil
+		}
+
+		return nil, convertError("float", args[0], types.TypeFloat)
+	},
+
+	"bool": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("bool", args, 1); err != nil {
+			return nil, err
+		}
+
+		switch a := args[0].(type) {
+		case *types.Null:
+			return objNull, nil
+
+		case *types.Boolean:
+			return a, nil
+
+		case *types.String:
+			if v, err := strconv.ParseBool(a.Value); err == nil {
+				return boolToObject(v), nil
+			}
+
+		case *types.Integer:
+			return boolToObject(a.Value != 0), nil
+
+		case *types.Float:
+			return boolToObject(a.Value != 0), nil
+
+		case *types.Decimal:
+			return boolToObject(a.Value.Sign() != 0), nil
+		}
+
+		return nil, convertError("bool", args[0], types.TypeBoolean)
+	},
+
+	"type": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("type", args, 1); err != nil {
+			return nil, err
+		}
+
+		return types.NewString(string(args[0].Type())), nil
+	},
+
+	"isNull":     typePredicate("isNull", types.TypeNull),
+	"isBool":     typePredicate("isBool", types.TypeBoolean),
+	"isInt":      typePredicate("isInt", types.TypeInteger),
+	"isFloat":    typePredicate("isFloat", types.TypeFloat),
+	"isDecimal":  typePredicate("isDecimal", types.TypeDecimal),
+	"isNumber":   typePredicate("isNumber", types.TypeInteger, types.TypeFloat, types.TypeDecimal),
+	"isString":   typePredicate("isString", types.TypeString),
+	"isArray":    typePredicate("isArray", types.TypeArray),
+	"isMap":      typePredicate("isMap", types.TypeMap),
+	"isTime":     typePredicate("isTime", types.TypeTime),
+	"isDuration": typePredicate("isDuration", types.TypeDuration),
+}
+
+func init() {
+	for k, v := range convertBuiltIns {
+		builtIns[k] = v
+	}
+}
+
+// typePredicate returns a function that returns true if the argument is one of the specified types
+func typePredicate(name string, ts ...types.Type) types.Func {
+	return func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen(name, args, 1); err != nil {
+			return nil, err
+		}
+
+		t := args[0].Type()
+		for _, pt := range ts {
+			if t == pt {
+				return objTrue, nil
+			}
+		}
+		return objFalse, nil
+	}
+}
+
+func convertError(name string, o types.Object, t types.Type) error {
+	return fmt.Errorf("%s: cannot convert %s to %s", name, o.Inspect(), strings.ToLower(string(t)))
+}
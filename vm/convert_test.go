@@ -0,0 +1,106 @@
+package vm_test
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stevecallear/mexl/types"
+	"github.com/stevecallear/mexl/vm"
+)
+
+func TestConvertBuiltIns(t *testing.T) {
+	tests := []testCase{
+		{
+			name: "string",
+			prog: compile(`[string(1), string(1.5), string(1.10d), string(true), string("a"), string([1, "a"]), string(x)]`),
+			exp:  []any{"1", "1.5", "1.1", "true", "a", `[1, "a"]`, nil},
+		},
+		{
+			name: "string time",
+			prog: compile("string(t)"),
+			env:  types.Map{"t": &types.Time{Value: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}},
+			exp:  "2024-01-02T00:00:00Z",
+		},
+		{
+			name: "string func error",
+			prog: compile("string(f)"),
+			env: types.Map{"f": types.Func(func(args ...types.Object) (types.Object, error) {
+				return nil, nil
+			})},
+			err: true,
+		},
+		{
+			name: "int",
+			prog: compile(`[int(1), int(1.9), int(-1.9), int(2.5d), int("42"), int(true), int(x)]`),
+			exp:  []any{1, 1, -1, 2, 42, 1, nil},
+		},
+		{
+			name: "int invalid string",
+			prog: compile(`int("1.5")`),
+			err:  true,
+		},
+		{
+			name: "int invalid float",
+			prog: compile("int(f)"),
+			env:  types.Map{"f": &types.Float{Value: 1e300}},
+			err:  true,
+		},
+		{
+			name: "int invalid type",
+			prog: compile("int([1])"),
+			err:  true,
+		},
+		{
+			name: "float",
+			prog: compile(`[float(1), float(1.5), float(0.25d), float("2.5"), float(false), float(x)]`),
+			exp:  []any{1.0, 1.5, 0.25, 2.5, 0.0, nil},
+		},
+		{
+			name: "float invalid string",
+			prog: compile(`float("a")`),
+			err:  true,
+		},
+		{
+			name: "bool",
+			prog: compile(`[bool(true), bool("false"), bool("1"), bool(0), bool(0.5), bool(0d), bool(x)]`),
+			exp:  []any{true, false, true, false, true, false, nil},
+		},
+		{
+			name: "bool invalid string",
+			prog: compile(`bool("yes")`),
+			err:  true,
+		},
+		{
+			name: "type",
+			prog: compile(`[type(1), type(1.5), type("a"), type([]), type({}), type(x), type(d)]`),
+			env:  types.Map{"d": &types.Decimal{Value: big.NewRat(1, 2)}},
+			exp:  []any{"INTEGER", "FLOAT", "STRING", "ARRAY", "MAP", "NULL", "DECIMAL"},
+		},
+		{
+			name: "predicates",
+			prog: compile(`[isNull(x), isNull(1), isString("a"), isInt(1.5), isFloat(1.5), isNumber(1d), isBool(false), isArray([]), isMap({}), isDecimal(1)]`),
+			exp:  []any{true, false, true, false, true, true, true, true, true, false},
+		},
+		{
+			name: "predicate args count error",
+			prog: compile("isNull()"),
+			err:  true,
+		},
+	}
+
+	testVM(t, tests)
+}
+
+func TestConvertBuiltIns_Error(t *testing.T) {
+	_, err := vm.New(compile(`int("1.5")`), nil).Run()
+	if err == nil {
+		t.Fatal("got nil, expected an error")
+	}
+
+	exp := `int: cannot convert "1.5" to integer`
+	if act := err.Error(); !strings.Contains(act, exp) {
+		t.Errorf("got %s, expected %s", act, exp)
+	}
+}
@@ -0,0 +1,107 @@
+package vm
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/stevecallear/mexl/types"
+)
+
+var decimalBuiltIns = map[string]types.Func{
+	"decimal": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("decimal", args, 1); err != nil {
+			return nil, err
+		}
+
+		switch args[0].Type() {
+		case types.TypeNull:
+			return objNull, nil
+
+		case types.TypeString, types.TypeInteger, types.TypeFloat, types.TypeDecimal:
+			o, ok := types.Convert(args[0], types.TypeDecimal)
+			if !ok {
+				return nil, fmt.Errorf("decimal: invalid decimal: %s", args[0].Inspect())
+			}
+			return o, nil
+
+		default:
+			return nil, fmt.Errorf("decimal: wrong arg type: %s, expected %s", args[0].Type(), types.TypeString)
+		}
+	},
+}
+
+func init() {
+	for k, v := range decimalBuiltIns {
+		builtIns[k] = v
+	}
+}
+
+func (vm *VM) execBinaryDecimalOp(op Opcode, left, right types.Object) error {
+	l := left.(*types.Decimal).Value
+	r := right.(*types.Decimal).Value
+
+	v := new(big.Rat)
+	switch op {
+	case OpAdd:
+		v.Add(l, r)
+
+	case OpSubtract:
+		v.Sub(l, r)
+
+	case OpMultiply:
+		v.Mul(l, r)
+
+	case OpDivide:
+		if r.Sign() == 0 {
+			return ErrDivisionByZero
+		}
+		v.Quo(l, r)
+
+	case OpModulus:
+		if r.Sign() == 0 {
+			return ErrDivisionByZero
+		}
+		v = modDecimal(l, r)
+
+	default:
+		return fmt.Errorf("unknown decimal operator: %d", op)
+	}
+
+	vm.push(&types.Decimal{Value: v})
+	return nil
+}
+
+// modDecimal returns the remainder of l / r truncated towards zero, with the sign of l
+func modDecimal(l, r *big.Rat) *big.Rat {
+	// l / r = (ln * rd) / (ld * rn)
+	q := new(big.Int).Quo(
+		new(big.Int).Mul(l.Num(), r.Denom()),
+		new(big.Int).Mul(l.Denom(), r.Num()),
+	)
+
+	v := new(big.Rat).Mul(r, new(big.Rat).SetInt(q))
+	return v.Sub(l, v)
+}
+
+func (vm *VM) execDecimalComparison(op Opcode, left, right types.Object) error {
+	c := left.(*types.Decimal).Value.Cmp(right.(*types.Decimal).Value)
+
+	switch op {
+	case OpLess:
+		vm.push(boolToObject(c < 0))
+
+	case OpLessOrEqual:
+		vm.push(boolToObject(c <= 0))
+
+	case OpGreater:
+		vm.push(boolToObject(c > 0))
+
+	case OpGreaterOrEqual:
+		vm.push(boolToObject(c >= 0))
+
+	default:
+		return fmt.Errorf("unknown decimal comparison operator: %d", op)
+	}
+
+	return nil
+}
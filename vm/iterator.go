@@ -0,0 +1,138 @@
+package vm
+
+import (
+	"fmt"
+
+	"github.com/stevecallear/mexl/types"
+)
+
+// IterKind represents the operation performed by a collection iteration
+type IterKind byte
+
+const (
+	IterAny IterKind = iota + 1
+	IterAll
+	IterNone
+	IterFilter
+	IterMap
+)
+
+// iterator holds the state of a collection iteration on the stack
+type iterator struct {
+	kind   IterKind
+	elems  types.Array
+	index  int
+	done   bool
+	result types.Object
+	values types.Array
+}
+
+var iterKinds = map[string]IterKind{
+	"any":    IterAny,
+	"all":    IterAll,
+	"none":   IterNone,
+	"filter": IterFilter,
+	"map":    IterMap,
+}
+
+// LookupIterKind returns the iteration kind for the named collection operation
+func LookupIterKind(name string) (IterKind, bool) {
+	k, ok := iterKinds[name]
+	return k, ok
+}
+
+func (it *iterator) Type() types.Type {
+	return "ITERATOR"
+}
+
+func (it *iterator) Equal(o types.Object) bool {
+	return it == o
+}
+
+func (it *iterator) Inspect() string {
+	return "iterator"
+}
+
+func (vm *VM) execIter(kind IterKind) error {
+	coll := vm.pop()
+
+	if err := vm.checkNull(coll); err != nil {
+		return err
+	}
+
+	it := &iterator{kind: kind, index: -1}
+
+	switch c := coll.(type) {
+	case types.Array:
+		it.elems = c
+	case *types.Null:
+	default:
+		return fmt.Errorf("unsupported type for iteration: %s", coll.Type())
+	}
+
+	switch kind {
+	case IterAny:
+		it.result = objFalse
+	case IterAll, IterNone:
+		it.result = objTrue
+	case IterFilter, IterMap:
+		it.values = make(types.Array, 0, len(it.elems))
+	default:
+		return fmt.Errorf("invalid iteration kind: %d", kind)
+	}
+
+	vm.push(it)
+	return nil
+}
+
+// execIterNext advances the iterator, assigning the element to the local slot
+// It returns false if the iteration is complete
+func (vm *VM) execIterNext(slot uint8) bool {
+	it := vm.peek().(*iterator)
+
+	it.index++
+	if it.done || it.index >= len(it.elems) {
+		return false
+	}
+
+	vm.locals[slot] = it.elems[it.index]
+	return true
+}
+
+func (vm *VM) execIterStep() error {
+	v := vm.pop()
+	it := vm.peek().(*iterator)
+
+	if it.kind == IterMap {
+		it.values = append(it.values, v)
+		return nil
+	}
+
+	b, err := vm.truthy(v)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case it.kind == IterAny && b:
+		it.result, it.done = objTrue, true
+	case it.kind == IterAll && !b:
+		it.result, it.done = objFalse, true
+	case it.kind == IterNone && b:
+		it.result, it.done = objFalse, true
+	case it.kind == IterFilter && b:
+		it.values = append(it.values, it.elems[it.index])
+	}
+
+	return nil
+}
+
+func (vm *VM) execIterEnd() {
+	it := vm.pop().(*iterator)
+
+	if it.values != nil {
+		vm.push(it.values)
+	} else {
+		vm.push(it.result)
+	}
+}
@@ -0,0 +1,67 @@
+package vm
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// like returns true if the string matches the wildcard pattern
+// Both % and * match any sequence of characters, while _ and ? match a single character.
+// A backslash matches the following character literally.
+func like(s, pattern string, fold bool) bool {
+	var si, pi int
+	star, match := -1, 0
+
+	for si < len(s) {
+		if pi < len(pattern) {
+			pr, pw := utf8.DecodeRuneInString(pattern[pi:])
+			sr, sw := utf8.DecodeRuneInString(s[si:])
+
+			switch pr {
+			case '%', '*':
+				star, match = pi+pw, si
+				pi += pw
+				continue
+
+			case '_', '?':
+				si += sw
+				pi += pw
+				continue
+
+			case '\\':
+				if pi+pw < len(pattern) {
+					pi += pw
+					pr, pw = utf8.DecodeRuneInString(pattern[pi:])
+				}
+			}
+
+			if equalRune(pr, sr, fold) {
+				si += sw
+				pi += pw
+				continue
+			}
+		}
+
+		if star < 0 {
+			return false
+		}
+
+		// backtrack, extending the sequence matched by the last wildcard
+		_, sw := utf8.DecodeRuneInString(s[match:])
+		match += sw
+		si, pi = match, star
+	}
+
+	for pi < len(pattern) && (pattern[pi] == '%' || pattern[pi] == '*') {
+		pi++
+	}
+
+	return pi == len(pattern)
+}
+
+func equalRune(a, b rune, fold bool) bool {
+	if a == b {
+		return true
+	}
+	return fold && unicode.ToLower(a) == unicode.ToLower(b)
+}
@@ -0,0 +1,461 @@
+package vm
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/stevecallear/mexl/types"
+)
+
+// maxDecimalExponent is the maximum magnitude of an exact decimal exponent
+const maxDecimalExponent = 1024
+
+// numberTypes describes the accepted number types in error messages
+var numberTypes = fmt.Sprintf("%s, %s or %s", types.TypeInteger, types.TypeFloat, types.TypeDecimal)
+
+var mathBuiltIns = map[string]types.Func{
+	"round": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsRange("round", args, 1, 2); err != nil {
+			return nil, err
+		}
+
+		var places int64
+		if len(args) == 2 {
+			switch p := args[1].(type) {
+			case *types.Null:
+			case *types.Integer:
+				if p.Value < 0 || p.Value > maxDecimalExponent {
+					return nil, fmt.Errorf("round: invalid places: %d", p.Value)
+				}
+				places = p.Value
+			default:
+				return nil, fmt.Errorf("round: wrong arg type: %s, expected %s", args[1].Type(), types.TypeInteger)
+			}
+		}
+
+		switch a := args[0].(type) {
+		case *types.Null:
+			return objNull, nil
+
+		case *types.Integer:
+			return a, nil
+
+		case *types.Float:
+			m := math.Pow10(int(places))
+			v := a.Value * m
+			if math.IsInf(m, 0) || math.IsInf(v, 0) || math.Abs(v) >= 1<<52 {
+				// the value has no fractional digits at the requested precision
+				return a, nil
+			}
+			return &types.Float{Value: math.Round(v) / m}, nil
+
+		case *types.Decimal:
+			return &types.Decimal{Value: roundDecimal(a.Value, places)}, nil
+
+		default:
+			return nil, fmt.Errorf("round: wrong arg type: %s, expected %s", args[0].Type(), numberTypes)
+		}
+	},
+
+	"floor": roundFunc("floor", math.Floor, floorDecimal),
+
+	"ceil": roundFunc("ceil", math.Ceil, func(r *big.Rat) *big.Rat {
+		v := floorDecimal(new(big.Rat).Neg(r))
+		return v.Neg(v)
+	}),
+
+	"abs": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("abs", args, 1); err != nil {
+			return nil, err
+		}
+
+		switch a := args[0].(type) {
+		case *types.Null:
+			return objNull, nil
+
+		case *types.Integer:
+			switch {
+			case a.Value == math.MinInt64:
+				return nil, fmt.Errorf("abs: integer overflow: %s", a.Inspect())
+			case a.Value < 0:
+				return types.NewInteger(-a.Value), nil
+			default:
+				return a, nil
+			}
+
+		case *types.Float:
+			return &types.Float{Value: math.Abs(a.Value)}, nil
+
+		case *types.Decimal:
+			return &types.Decimal{Value: new(big.Rat).Abs(a.Value)}, nil
+
+		default:
+			return nil, fmt.Errorf("abs: wrong arg type: %s, expected %s", args[0].Type(), numberTypes)
+		}
+	},
+
+	"min": func(args ...types.Object) (types.Object, error) {
+		return extremum("min", args, -1)
+	},
+
+	"max": func(args ...types.Object) (types.Object, error) {
+		return extremum("max", args, 1)
+	},
+
+	"pow": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("pow", args, 2); err != nil {
+			return nil, err
+		}
+
+		ns, err := numbers("pow", args)
+		if err != nil || len(ns) < 2 {
+			return objNull, err
+		}
+
+		switch x := ns[0].(type) {
+		case *types.Integer:
+			if y := ns[1].(*types.Integer).Value; y >= 0 {
+				if v, ok := powInt(x.Value, y); ok {
+					return types.NewInteger(v), nil
+				}
+			}
+
+		case *types.Decimal:
+			// decimals are only exact for integer exponents
+			if y := ns[1].(*types.Decimal).Value; y.IsInt() && y.Num().IsInt64() {
+				e := y.Num().Int64()
+				switch {
+				case x.Value.Sign() == 0 && e < 0:
+					return nil, ErrDivisionByZero
+				case e > maxDecimalExponent || e < -maxDecimalExponent:
+					return nil, fmt.Errorf("pow: invalid exponent: %d", e)
+				}
+				return &types.Decimal{Value: powDecimal(x.Value, e)}, nil
+			}
+		}
+
+		x, _ := types.Convert(ns[0], types.TypeFloat)
+		y, _ := types.Convert(ns[1], types.TypeFloat)
+		return &types.Float{Value: math.Pow(x.(*types.Float).Value, y.(*types.Float).Value)}, nil
+	},
+
+	"sqrt": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("sqrt", args, 1); err != nil {
+			return nil, err
+		}
+
+		ns, err := numbers("sqrt", args)
+		if err != nil || len(ns) < 1 {
+			return objNull, err
+		}
+
+		o, _ := types.Convert(ns[0], types.TypeFloat)
+		f := o.(*types.Float).Value
+		if f < 0 {
+			return nil, fmt.Errorf("sqrt: invalid argument: %s", args[0].Inspect())
+		}
+
+		v := math.Sqrt(f)
+
+		// perfect squares of integers result in an integer
+		if i, ok := ns[0].(*types.Integer); ok && v == math.Trunc(v) && int64(v)*int64(v) == i.Value {
+			return types.NewInteger(int64(v)), nil
+		}
+		return &types.Float{Value: v}, nil
+	},
+
+	"clamp": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("clamp", args, 3); err != nil {
+			return nil, err
+		}
+
+		if args[0].Type() == types.TypeNull {
+			return objNull, nil
+		}
+
+		ns, err := numbers("clamp", args)
+		if err != nil {
+			return nil, err
+		}
+
+		// null bounds are ignored
+		x, i := ns[0], 1
+		var lo, hi types.Object
+		if args[1].Type() != types.TypeNull {
+			lo, i = ns[i], i+1
+		}
+		if args[2].Type() != types.TypeNull {
+			hi = ns[i]
+		}
+
+		if lo != nil && hi != nil && types.Compare(lo, hi) > 0 {
+			return nil, fmt.Errorf("clamp: invalid bounds: %s, %s", args[1].Inspect(), args[2].Inspect())
+		}
+
+		switch {
+		case lo != nil && types.Compare(x, lo) < 0:
+			return lo, nil
+		case hi != nil && types.Compare(x, hi) > 0:
+			return hi, nil
+		default:
+			return x, nil
+		}
+	},
+
+	"sum": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("sum", args, 1); err != nil {
+			return nil, err
+		}
+
+		ns, err := arrayNumbers("sum", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return sumNumbers("sum", ns)
+	},
+
+	"avg": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("avg", args, 1); err != nil {
+			return nil, err
+		}
+
+		ns, err := arrayNumbers("avg", args[0])
+		if err != nil || len(ns) < 1 {
+			return objNull, err
+		}
+
+		o, err := sumNumbers("avg", ns)
+		if err != nil {
+			return nil, err
+		}
+
+		switch s := o.(type) {
+		case *types.Integer:
+			// the average is an integer if the division is exact
+			n := int64(len(ns))
+			if s.Value%n == 0 {
+				return types.NewInteger(s.Value / n), nil
+			}
+			return &types.Float{Value: float64(s.Value) / float64(n)}, nil
+
+		case *types.Float:
+			return &types.Float{Value: s.Value / float64(len(ns))}, nil
+
+		default:
+			d := s.(*types.Decimal).Value
+			return &types.Decimal{Value: new(big.Rat).Quo(d, new(big.Rat).SetInt64(int64(len(ns))))}, nil
+		}
+	},
+}
+
+func init() {
+	for k, v := range mathBuiltIns {
+		builtIns[k] = v
+	}
+}
+
+// roundFunc returns a function that rounds a numeric argument to an integral value of the same type
+func roundFunc(name string, ffn func(float64) float64, dfn func(*big.Rat) *big.Rat) types.Func {
+	return func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen(name, args, 1); err != nil {
+			return nil, err
+		}
+
+		switch a := args[0].(type) {
+		case *types.Null:
+			return objNull, nil
+
+		case *types.Integer:
+			return a, nil
+
+		case *types.Float:
+			return &types.Float{Value: ffn(a.Value)}, nil
+
+		case *types.Decimal:
+			return &types.Decimal{Value: dfn(a.Value)}, nil
+
+		default:
+			return nil, fmt.Errorf("%s: wrong arg type: %s, expected %s", name, args[0].Type(), numberTypes)
+		}
+	}
+}
+
+// roundDecimal rounds the value half away from zero to the specified number of places
+func roundDecimal(r *big.Rat, places int64) *big.Rat {
+	m := new(big.Int).Exp(big.NewInt(10), big.NewInt(places), nil)
+	v := new(big.Rat).Mul(r, new(big.Rat).SetInt(m))
+
+	q, rem := new(big.Int).QuoRem(v.Num(), v.Denom(), new(big.Int))
+	if rem.Abs(rem).Lsh(rem, 1).Cmp(v.Denom()) >= 0 {
+		q.Add(q, big.NewInt(int64(v.Sign())))
+	}
+
+	return new(big.Rat).SetFrac(q, m)
+}
+
+// floorDecimal returns the greatest integral value less than or equal to the value
+func floorDecimal(r *big.Rat) *big.Rat {
+	// euclidean division rounds towards negative infinity for a positive denominator
+	q := new(big.Int).Div(r.Num(), r.Denom())
+	return new(big.Rat).SetInt(q)
+}
+
+// extremum returns the minimum or maximum of the numbers, or of a single array of numbers
+func extremum(name string, args []types.Object, sign int) (types.Object, error) {
+	if err := expectArgsRange(name, args, 1, -1); err != nil {
+		return nil, err
+	}
+
+	var ns []types.Object
+	var err error
+	if len(args) == 1 && args[0].Type() == types.TypeArray {
+		ns, err = arrayNumbers(name, args[0])
+	} else {
+		ns, err = numbers(name, args)
+	}
+	if err != nil || len(ns) < 1 {
+		return objNull, err
+	}
+
+	r := ns[0]
+	for _, n := range ns[1:] {
+		if types.Compare(n, r)*sign > 0 {
+			r = n
+		}
+	}
+
+	return r, nil
+}
+
+// arrayNumbers returns the numbers in the array argument
+func arrayNumbers(name string, o types.Object) ([]types.Object, error) {
+	switch a := o.(type) {
+	case *types.Null:
+		return nil, nil
+	case types.Array:
+		return numbers(name, a)
+	default:
+		return nil, fmt.Errorf("%s: wrong arg type: %s, expected %s", name, o.Type(), types.TypeArray)
+	}
+}
+
+// numbers returns the non-null numbers converted to the widest number type, following types.Coerce
+func numbers(name string, objs []types.Object) ([]types.Object, error) {
+	var t types.Type
+	ns := make([]types.Object, 0, len(objs))
+
+	for _, o := range objs {
+		switch ot := o.Type(); ot {
+		case types.TypeNull:
+			continue
+
+		case types.TypeInteger, types.TypeFloat, types.TypeDecimal:
+			if numberRank(ot) > numberRank(t) {
+				t = ot
+			}
+			ns = append(ns, o)
+
+		default:
+			return nil, fmt.Errorf("%s: wrong arg type: %s, expected %s", name, ot, numberTypes)
+		}
+	}
+
+	for i, n := range ns {
+		if n.Type() != t {
+			c, ok := types.Convert(n, t)
+			if !ok {
+				return nil, fmt.Errorf("%s: invalid %s: %s", name, strings.ToLower(string(t)), n.Inspect())
+			}
+			ns[i] = c
+		}
+	}
+
+	return ns, nil
+}
+
+// numberRank orders the number types by promotion
+func numberRank(t types.Type) int {
+	switch t {
+	case types.TypeInteger:
+		return 1
+	case types.TypeFloat:
+		return 2
+	case types.TypeDecimal:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// sumNumbers returns the sum of numbers of the same type
+func sumNumbers(name string, ns []types.Object) (types.Object, error) {
+	if len(ns) < 1 {
+		return types.NewInteger(0), nil
+	}
+
+	switch ns[0].(type) {
+	case *types.Integer:
+		var v int64
+		for _, n := range ns {
+			x := n.(*types.Integer).Value
+			r := v + x
+			if (x > 0 && r < v) || (x < 0 && r > v) {
+				return nil, fmt.Errorf("%s: integer overflow: %s", name, n.Inspect())
+			}
+			v = r
+		}
+		return types.NewInteger(v), nil
+
+	case *types.Float:
+		var v float64
+		for _, n := range ns {
+			v += n.(*types.Float).Value
+		}
+		return &types.Float{Value: v}, nil
+
+	default:
+		v := new(big.Rat)
+		for _, n := range ns {
+			v.Add(v, n.(*types.Decimal).Value)
+		}
+		return &types.Decimal{Value: v}, nil
+	}
+}
+
+// powInt returns x to the power of y, or false if the result overflows
+func powInt(x, y int64) (int64, bool) {
+	switch {
+	case y == 0:
+		return 1, true
+	case x == 0 || x == 1:
+		return x, true
+	case x == -1:
+		return 1 - 2*(y%2), true
+	case y >= 64:
+		return 0, false
+	}
+
+	r := int64(1)
+	for ; y > 0; y-- {
+		v := r * x
+		if x != 0 && v/x != r {
+			return 0, false
+		}
+		r = v
+	}
+	return r, true
+}
+
+// powDecimal returns x to the power of y
+func powDecimal(x *big.Rat, y int64) *big.Rat {
+	n, d := x.Num(), x.Denom()
+	if y < 0 {
+		n, d, y = d, n, -y
+	}
+
+	e := big.NewInt(y)
+	return new(big.Rat).SetFrac(new(big.Int).Exp(n, e, nil), new(big.Int).Exp(d, e, nil))
+}
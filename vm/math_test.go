@@ -0,0 +1,152 @@
+package vm_test
+
+import (
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stevecallear/mexl/types"
+	"github.com/stevecallear/mexl/vm"
+)
+
+func TestMathBuiltIns(t *testing.T) {
+	dec := func(n, d int64) *types.Decimal {
+		return &types.Decimal{Value: big.NewRat(n, d)}
+	}
+
+	tests := []testCase{
+		{
+			name: "abs",
+			prog: compile("[abs(-2), abs(2), abs(-1.5), abs(-1.5d), abs(x)]"),
+			exp:  []any{2, 2, 1.5, dec(3, 2), nil},
+		},
+		{
+			name: "abs overflow",
+			prog: compile("abs(-9223372036854775807 - 1)"),
+			err:  true,
+		},
+		{
+			name: "abs arg type error",
+			prog: compile(`abs("a")`),
+			err:  true,
+		},
+		{
+			name: "min",
+			prog: compile("[min(3, 1, 2), min(3, 1.5), min(2, 1.5d), min([3, 1]), min(x, 1), min([])]"),
+			exp:  []any{1, 1.5, dec(3, 2), 1, 1, nil},
+		},
+		{
+			name: "max",
+			prog: compile("[max(3, 1, 2), max(3, 1.5), max(2, 1.5d), max([3, 1]), max(x)]"),
+			exp:  []any{3, 3.0, dec(2, 1), 3, nil},
+		},
+		{
+			name: "min args count error",
+			prog: compile("min()"),
+			err:  true,
+		},
+		{
+			name: "max arg type error",
+			prog: compile(`max(1, "a")`),
+			err:  true,
+		},
+		{
+			name: "pow",
+			prog: compile("[pow(2, 10), pow(2, -1), pow(4, 0.5), pow(1.5d, 2), pow(2d, -2), pow(x, 2)]"),
+			exp:  []any{1024, 0.5, 2.0, dec(9, 4), dec(1, 4), nil},
+		},
+		{
+			name: "pow overflow",
+			prog: compile("pow(2, 64)"),
+			exp:  18446744073709551616.0,
+		},
+		{
+			name: "pow decimal division by zero",
+			prog: compile("pow(0d, -1)"),
+			err:  true,
+		},
+		{
+			name: "sqrt",
+			prog: compile("[sqrt(16), sqrt(2.25), sqrt(6.25d), sqrt(x)]"),
+			exp:  []any{4, 1.5, 2.5, nil},
+		},
+		{
+			name: "sqrt negative",
+			prog: compile("sqrt(-1)"),
+			err:  true,
+		},
+		{
+			name: "clamp",
+			prog: compile("[clamp(5, 1, 3), clamp(0, 1, 3), clamp(2, 1, 3), clamp(1.5, 1, 3), clamp(5, null, 3), clamp(x, 1, 3)]"),
+			exp:  []any{3, 1, 2, 1.5, 3, nil},
+		},
+		{
+			name: "clamp invalid bounds",
+			prog: compile("clamp(1, 3, 1)"),
+			err:  true,
+		},
+		{
+			name: "sum",
+			prog: compile("[sum([1, 2, 3]), sum([1, 2.5]), sum([0.1d, 0.2]), sum([1, null]), sum([]), sum(x)]"),
+			exp:  []any{6, 3.5, dec(3, 10), 1, 0, 0},
+		},
+		{
+			name: "sum arg type error",
+			prog: compile(`sum(["a"])`),
+			err:  true,
+		},
+		{
+			name: "avg",
+			prog: compile("[avg([1, 2, 3]), avg([1, 2]), avg([1.5, 2.5]), avg([1d, 2d]), avg([]), avg(x)]"),
+			exp:  []any{2, 1.5, 2.0, dec(3, 2), nil, nil},
+		},
+		{
+			name: "avg arg type error",
+			prog: compile(`avg(["a"])`),
+			err:  true,
+		},
+		{
+			name: "sum overflow error",
+			prog: compile("sum([x, 1])"),
+			env:  types.Map{"x": types.NewInteger(math.MaxInt64)},
+			err:  true,
+		},
+		{
+			name: "avg overflow error",
+			prog: compile("avg([x, -1])"),
+			env:  types.Map{"x": types.NewInteger(math.MinInt64)},
+			err:  true,
+		},
+	}
+
+	testVM(t, tests)
+}
+
+func TestMathErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		exp   string
+	}{
+		{`sum(["a"])`, "sum: wrong arg type: STRING, expected INTEGER, FLOAT or DECIMAL"},
+		{`avg([1, true])`, "avg: wrong arg type: BOOLEAN, expected INTEGER, FLOAT or DECIMAL"},
+		{`abs("a")`, "abs: wrong arg type: STRING, expected INTEGER, FLOAT or DECIMAL"},
+		{"sum([max, 1])", "sum: integer overflow: 1"},
+		{"avg([min, -1])", "avg: integer overflow: -1"},
+		{"abs(min)", "abs: integer overflow: -9223372036854775808"},
+	}
+
+	env := types.Map{
+		"max": types.NewInteger(math.MaxInt64),
+		"min": types.NewInteger(math.MinInt64),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := vm.New(compile(tt.input), env).Run()
+			if err == nil || !strings.Contains(err.Error(), tt.exp) {
+				t.Errorf("got %v, expected %s", err, tt.exp)
+			}
+		})
+	}
+}
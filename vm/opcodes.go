@@ -46,6 +46,29 @@ const (
 	OpCall
 	OpJumpIfTrue
 	OpJumpIfFalse
+	OpFunction
+	OpMap
+	OpJump
+	OpPop
+	OpJumpIfNotNull
+	OpLocal
+	OpIter
+	OpIterNext
+	OpIterStep
+	OpIterEnd
+	OpSlice
+	OpMatch
+	OpMatchPattern
+	OpFindPattern
+	OpLike
+	OpILike
+	OpBuiltIn
+)
+
+// Bound flags for the OpSlice operand
+const (
+	SliceLow = 1 << iota
+	SliceHigh
 )
 
 var definitions = map[Opcode]*Definition{
@@ -78,6 +101,23 @@ var definitions = map[Opcode]*Definition{
 	OpCall:           {"OpCall", []int{1}},
 	OpJumpIfTrue:     {"OpJumpIfTrue", []int{2}},
 	OpJumpIfFalse:    {"OpJumpIfFalse", []int{2}},
+	OpFunction:       {"OpFunction", []int{1}},
+	OpMap:            {"OpMap", []int{2}},
+	OpJump:           {"OpJump", []int{2}},
+	OpPop:            {"OpPop", []int{}},
+	OpJumpIfNotNull:  {"OpJumpIfNotNull", []int{2}},
+	OpLocal:          {"OpLocal", []int{1}},
+	OpIter:           {"OpIter", []int{1}},
+	OpIterNext:       {"OpIterNext", []int{1, 2}},
+	OpIterStep:       {"OpIterStep", []int{}},
+	OpIterEnd:        {"OpIterEnd", []int{}},
+	OpSlice:          {"OpSlice", []int{1}},
+	OpMatch:          {"OpMatch", []int{}},
+	OpMatchPattern:   {"OpMatchPattern", []int{2}},
+	OpFindPattern:    {"OpFindPattern", []int{2}},
+	OpLike:           {"OpLike", []int{}},
+	OpILike:          {"OpILike", []int{}},
+	OpBuiltIn:        {"OpBuiltIn", []int{1, 1}},
 }
 
 func Make(op Opcode, operands ...int) []byte {
@@ -174,6 +214,8 @@ func formatInstruction(def *Definition, operands []int) string {
 		return def.Name
 	case 1:
 		return fmt.Sprintf("%s %d", def.Name, operands[0])
+	case 2:
+		return fmt.Sprintf("%s %d %d", def.Name, operands[0], operands[1])
 	default:
 		return fmt.Sprintf("ERROR: unhandled operand count: %d", count)
 	}
@@ -1,6 +1,11 @@
 package vm
 
-import "github.com/stevecallear/mexl/types"
+import (
+	"regexp"
+
+	"github.com/stevecallear/mexl/ast/token"
+	"github.com/stevecallear/mexl/types"
+)
 
 type (
 	Instructions []byte
@@ -9,5 +14,17 @@ type (
 		Instructions Instructions
 		Constants    []types.Object
 		Identifiers  []string
+		Functions    []types.Object
+		Patterns     []*regexp.Regexp
+		Locals       int
+		Source       string
+		Spans        map[int]Span
+		Calls        map[int]string
+	}
+
+	// Span represents the source span that produced an instruction
+	Span struct {
+		Start token.Position
+		End   token.Position
 	}
 )
@@ -0,0 +1,99 @@
+package vm
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/stevecallear/mexl/types"
+)
+
+// execMatch matches the string against a pattern that is compiled at runtime
+func (vm *VM) execMatch() error {
+	p := vm.pop()
+	s := vm.pop()
+
+	if err := vm.checkNull(s, p); err != nil {
+		return err
+	}
+
+	m, err := regexMatch("matches", s, p)
+	if err != nil {
+		return err
+	}
+
+	vm.push(m)
+	return nil
+}
+
+// execPattern matches or finds the precompiled pattern in the string
+// The name of the operator or function is used in error messages.
+func (vm *VM) execPattern(op Opcode, re *regexp.Regexp, name string) error {
+	s := vm.pop()
+
+	if err := vm.checkNull(s); err != nil {
+		return err
+	}
+
+	if name == "" {
+		name = "matches"
+	}
+
+	str, err := stringArg(name, s)
+	if err != nil {
+		return err
+	}
+
+	if op == OpFindPattern {
+		vm.push(find(re, str))
+	} else {
+		vm.push(boolToObject(re.MatchString(str)))
+	}
+
+	return nil
+}
+
+func regexMatch(name string, s, p types.Object) (types.Object, error) {
+	str, re, err := patternArgs(name, s, p)
+	if err != nil {
+		return nil, err
+	}
+
+	return boolToObject(re.MatchString(str)), nil
+}
+
+func regexFind(name string, s, p types.Object) (types.Object, error) {
+	str, re, err := patternArgs(name, s, p)
+	if err != nil {
+		return nil, err
+	}
+
+	return find(re, str), nil
+}
+
+func find(re *regexp.Regexp, s string) types.Object {
+	loc := re.FindStringIndex(s)
+	if loc == nil {
+		return objNull
+	}
+
+	return types.NewString(s[loc[0]:loc[1]])
+}
+
+func patternArgs(name string, s, p types.Object) (string, *regexp.Regexp, error) {
+	str, err := stringArg(name, s)
+	if err != nil {
+		return "", nil, err
+	}
+
+	pattern, err := stringArg(name, p)
+	if err != nil {
+		return "", nil, err
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: invalid pattern: %w", name, err)
+	}
+
+	return str, re, nil
+}
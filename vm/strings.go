@@ -0,0 +1,378 @@
+package vm
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/stevecallear/mexl/types"
+)
+
+// MaxStringLength is the maximum length in bytes of a string built by repeat or padLeft
+const MaxStringLength = 1 << 20
+
+var stringBuiltIns = map[string]types.Func{
+	"trim": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("trim", args, 1); err != nil {
+			return nil, err
+		}
+
+		return stringFunc("trim", args, func(s []string) types.Object {
+			return types.NewString(strings.TrimSpace(s[0]))
+		})
+	},
+
+	"trimPrefix": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("trimPrefix", args, 2); err != nil {
+			return nil, err
+		}
+
+		return stringFunc("trimPrefix", args, func(s []string) types.Object {
+			return types.NewString(strings.TrimPrefix(s[0], s[1]))
+		})
+	},
+
+	"trimSuffix": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("trimSuffix", args, 2); err != nil {
+			return nil, err
+		}
+
+		return stringFunc("trimSuffix", args, func(s []string) types.Object {
+			return types.NewString(strings.TrimSuffix(s[0], s[1]))
+		})
+	},
+
+	"split": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("split", args, 2); err != nil {
+			return nil, err
+		}
+
+		return stringFunc("split", args, func(s []string) types.Object {
+			parts := strings.Split(s[0], s[1])
+
+			a := make(types.Array, len(parts))
+			for i, p := range parts {
+				a[i] = types.NewString(p)
+			}
+			return a
+		})
+	},
+
+	"join": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("join", args, 2); err != nil {
+			return nil, err
+		}
+
+		sep, err := stringArg("join", args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		switch a := args[0].(type) {
+		case *types.Null:
+			return objNull, nil
+
+		case types.Array:
+			elems := make([]string, len(a))
+			for i, e := range a {
+				if elems[i], err = stringArg("join", e); err != nil {
+					return nil, err
+				}
+			}
+			return types.NewString(strings.Join(elems, sep)), nil
+
+		default:
+			return nil, fmt.Errorf("join: wrong arg type: %s, expected %s", args[0].Type(), types.TypeArray)
+		}
+	},
+
+	"replace": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("replace", args, 3); err != nil {
+			return nil, err
+		}
+
+		return stringFunc("replace", args, func(s []string) types.Object {
+			return types.NewString(strings.ReplaceAll(s[0], s[1], s[2]))
+		})
+	},
+
+	"indexOf": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("indexOf", args, 2); err != nil {
+			return nil, err
+		}
+
+		return stringFunc("indexOf", args, func(s []string) types.Object {
+			// the index is returned in runes to match string indexing
+			i := strings.Index(s[0], s[1])
+			if i > 0 {
+				i = utf8.RuneCountInString(s[0][:i])
+			}
+			return types.NewInteger(int64(i))
+		})
+	},
+
+	"substr": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsRange("substr", args, 2, 3); err != nil {
+			return nil, err
+		}
+
+		s, err := stringArg("substr", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		start, err := intArg("substr", args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		rs := []rune(s)
+		n := int64(len(rs))
+		if start < 0 {
+			start = max(n+start, 0)
+		}
+		start = min(start, n)
+
+		end := n
+		if len(args) > 2 && args[2].Type() != types.TypeNull {
+			l, err := intArg("substr", args[2])
+			if err != nil {
+				return nil, err
+			}
+			end = start + min(max(l, 0), n-start)
+		}
+
+		if args[0].Type() == types.TypeNull {
+			return objNull, nil
+		}
+
+		return types.NewString(string(rs[start:end])), nil
+	},
+
+	"repeat": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("repeat", args, 2); err != nil {
+			return nil, err
+		}
+
+		s, err := stringArg("repeat", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		n, err := intArg("repeat", args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		if n < 0 || len(s) > 0 && n > int64(MaxStringLength/len(s)) {
+			return nil, fmt.Errorf("repeat: invalid count: %d", n)
+		}
+
+		if args[0].Type() == types.TypeNull {
+			return objNull, nil
+		}
+
+		return types.NewString(strings.Repeat(s, int(n))), nil
+	},
+
+	"padLeft": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsRange("padLeft", args, 2, 3); err != nil {
+			return nil, err
+		}
+
+		s, err := stringArg("padLeft", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		w, err := intArg("padLeft", args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		pad := " "
+		if len(args) > 2 && args[2].Type() != types.TypeNull {
+			if pad, err = stringArg("padLeft", args[2]); err != nil {
+				return nil, err
+			}
+		}
+
+		if args[0].Type() == types.TypeNull {
+			return objNull, nil
+		}
+
+		n := w - int64(utf8.RuneCountInString(s))
+		if n <= 0 || pad == "" {
+			return args[0], nil
+		}
+		// the pad is repeated in full, followed by the leading runes required to reach the width
+		pr := []rune(pad)
+		k, tail := n/int64(len(pr)), string(pr[:n%int64(len(pr))])
+		if n > MaxStringLength || k*int64(len(pad))+int64(len(tail)+len(s)) > MaxStringLength {
+			return nil, fmt.Errorf("padLeft: invalid width: %d", w)
+		}
+
+		return types.NewString(strings.Repeat(pad, int(k)) + tail + s), nil
+	},
+
+	"format": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsRange("format", args, 1, -1); err != nil {
+			return nil, err
+		}
+
+		f, err := stringArg("format", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if args[0].Type() == types.TypeNull {
+			return objNull, nil
+		}
+
+		r, err := format(f, args[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		return types.NewString(r), nil
+	},
+}
+
+func init() {
+	for k, v := range stringBuiltIns {
+		builtIns[k] = v
+	}
+}
+
+// stringFunc applies the function to the string arguments, returning null if the first argument is null
+func stringFunc(name string, args []types.Object, fn func([]string) types.Object) (types.Object, error) {
+	s, err := stringArgs(name, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if args[0].Type() == types.TypeNull {
+		return objNull, nil
+	}
+
+	return fn(s), nil
+}
+
+// stringArgs returns the string values of the arguments, coercing nulls to empty strings
+func stringArgs(name string, args []types.Object) ([]string, error) {
+	s := make([]string, len(args))
+
+	var err error
+	for i, a := range args {
+		if s[i], err = stringArg(name, a); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// stringArg returns the string value of the object, coercing null to an empty string
+func stringArg(name string, o types.Object) (string, error) {
+	switch v := o.(type) {
+	case *types.String:
+		return v.Value, nil
+	case *types.Null:
+		return "", nil
+	default:
+		return "", fmt.Errorf("%s: wrong arg type: %s, expected %s", name, o.Type(), types.TypeString)
+	}
+}
+
+// intArg returns the integer value of the object, coercing null to zero
+func intArg(name string, o types.Object) (int64, error) {
+	switch v := o.(type) {
+	case *types.Integer:
+		return v.Value, nil
+	case *types.Null:
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("%s: wrong arg type: %s, expected %s", name, o.Type(), types.TypeInteger)
+	}
+}
+
+// format formats the arguments using fmt verbs
+// Each directive is formatted separately so that missing or extra arguments and bad verbs
+// are returned as errors rather than fmt error strings.
+func format(f string, args []types.Object) (string, error) {
+	var sb strings.Builder
+	n := 0
+
+	for i := 0; i < len(f); i++ {
+		if f[i] != '%' {
+			sb.WriteByte(f[i])
+			continue
+		}
+
+		j := i + 1
+		for j < len(f) && strings.IndexByte("+-# 0123456789.", f[j]) >= 0 {
+			j++
+		}
+
+		if j >= len(f) {
+			return "", fmt.Errorf("format: invalid format: missing verb at %d", i)
+		}
+
+		verb, size := utf8.DecodeRuneInString(f[j:])
+		switch verb {
+		case '%':
+			sb.WriteByte('%')
+			i = j
+			continue
+		case '*', '[':
+			return "", fmt.Errorf("format: invalid format: unsupported %q at %d", verb, j)
+		}
+
+		if n >= len(args) {
+			return "", fmt.Errorf("format: missing argument for %s", f[i:j+size])
+		}
+
+		arg := formatArg(args[n], verb)
+		s := fmt.Sprintf(f[i:j+size], arg)
+		if strings.Contains(s, "%!") && !strings.Contains(fmt.Sprint(arg), "%!") {
+			return "", fmt.Errorf("format: wrong arg type for %s: %s", f[i:j+size], args[n].Type())
+		}
+
+		sb.WriteString(s)
+		i = j + size - 1
+		n++
+	}
+
+	if n < len(args) {
+		return "", fmt.Errorf("format: wrong number of arguments: %d, expected %d", len(args)+1, n+1)
+	}
+
+	return sb.String(), nil
+}
+
+// formatArg returns the native value of the object for use with fmt verbs
+// Null is coerced to the zero value for the verb.
+func formatArg(o types.Object, verb rune) any {
+	switch v := o.(type) {
+	case *types.Null:
+		switch verb {
+		case 'd', 'b', 'o', 'c', 'U':
+			return int64(0)
+		case 'e', 'E', 'f', 'F', 'g', 'G':
+			return float64(0)
+		case 't':
+			return false
+		default:
+			return ""
+		}
+	case *types.Decimal:
+		// big.Rat does not support fmt verbs, so the value is formatted as a float
+		return new(big.Float).SetPrec(256).SetRat(v.Value)
+	case types.Array, types.Map:
+		return v.Inspect()
+	default:
+		n, _ := types.ToNative(o)
+		return n
+	}
+}
@@ -0,0 +1,214 @@
+package vm_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stevecallear/mexl/types"
+)
+
+func TestStringBuiltIns(t *testing.T) {
+	tests := []testCase{
+		{
+			name: "trim",
+			prog: compile(`trim("  a b  ")`),
+			exp:  "a b",
+		},
+		{
+			name: "trim null",
+			prog: compile("trim(x)"),
+			exp:  nil,
+		},
+		{
+			name: "trim args count error",
+			prog: compile(`trim("a", "b")`),
+			err:  true,
+		},
+		{
+			name: "trim arg type error",
+			prog: compile("trim(1)"),
+			err:  true,
+		},
+		{
+			name: "trimPrefix",
+			prog: compile(`trimPrefix("abc", "ab")`),
+			exp:  "c",
+		},
+		{
+			name: "trimPrefix null prefix",
+			prog: compile(`trimPrefix("abc", x)`),
+			exp:  "abc",
+		},
+		{
+			name: "trimSuffix",
+			prog: compile(`trimSuffix("abc", "bc")`),
+			exp:  "a",
+		},
+		{
+			name: "split",
+			prog: compile(`split("a,b,c", ",")`),
+			exp:  []any{"a", "b", "c"},
+		},
+		{
+			name: "split null",
+			prog: compile(`split(x, ",")`),
+			exp:  nil,
+		},
+		{
+			name: "join",
+			prog: compile(`join(["a", "b", null], "-")`),
+			exp:  "a-b-",
+		},
+		{
+			name: "join null",
+			prog: compile(`join(x, "-")`),
+			exp:  nil,
+		},
+		{
+			name: "join element type error",
+			prog: compile(`join(["a", 1], "-")`),
+			err:  true,
+		},
+		{
+			name: "join arg type error",
+			prog: compile(`join("a", "-")`),
+			err:  true,
+		},
+		{
+			name: "replace",
+			prog: compile(`replace("a-b-c", "-", "+")`),
+			exp:  "a+b+c",
+		},
+		{
+			name: "contains",
+			prog: compile(`[contains("abc", "b"), contains("abc", "d"), contains(x, "a")]`),
+			exp:  []any{true, false, false},
+		},
+		{
+			name: "indexOf",
+			prog: compile(`[indexOf("héllo", "l"), indexOf("abc", "d"), indexOf(x, "a")]`),
+			exp:  []any{2, -1, nil},
+		},
+		{
+			name: "substr",
+			prog: compile(`[substr("héllo", 1), substr("héllo", 1, 2), substr("héllo", -3, 2), substr("abc", 5)]`),
+			exp:  []any{"éllo", "él", "ll", ""},
+		},
+		{
+			name: "substr null",
+			prog: compile("substr(x, 1)"),
+			exp:  nil,
+		},
+		{
+			name: "substr args count error",
+			prog: compile(`substr("a")`),
+			err:  true,
+		},
+		{
+			name: "substr arg type error",
+			prog: compile(`substr("a", "b")`),
+			err:  true,
+		},
+		{
+			name: "repeat",
+			prog: compile(`repeat("ab", 3)`),
+			exp:  "ababab",
+		},
+		{
+			name: "repeat invalid count",
+			prog: compile(`repeat("ab", -1)`),
+			err:  true,
+		},
+		{
+			name: "padLeft",
+			prog: compile(`[padLeft("7", 3, "0"), padLeft("7", 3), padLeft("1234", 3, "0"), padLeft("1", 4, "ab")]`),
+			exp:  []any{"007", "  7", "1234", "aba1"},
+		},
+		{
+			name: "repeat max length",
+			prog: compile(`[len(repeat("ab", 524288)), len(padLeft("1", 1048576, "ab")), len(padLeft("", 524288, "é"))]`),
+			exp:  []any{1048576, 1048576, 1048576},
+		},
+		{
+			name: "repeat exceeds max length",
+			prog: compile(`repeat("ab", 524289)`),
+			err:  true,
+		},
+		{
+			name: "padLeft exceeds max length",
+			prog: compile(`padLeft("1", 1048577)`),
+			err:  true,
+		},
+		{
+			name: "padLeft multibyte pad exceeds max length",
+			prog: compile(`padLeft("", 524289, "é")`),
+			err:  true,
+		},
+		{
+			name: "padLeft invalid width",
+			prog: compile(`padLeft("", 9223372036854775807)`),
+			err:  true,
+		},
+		{
+			name: "padLeft null",
+			prog: compile(`padLeft(x, 3, "0")`),
+			exp:  nil,
+		},
+		{
+			name: "format",
+			prog: compile(`format("%s has %d items costing %.2f", name, 3, price)`),
+			env: types.Map{
+				"name":  &types.String{Value: "basket"},
+				"price": &types.Decimal{Value: big.NewRat(1999, 100)},
+			},
+			exp: "basket has 3 items costing 19.99",
+		},
+		{
+			name: "format null",
+			prog: compile("format(x, 1)"),
+			exp:  nil,
+		},
+		{
+			name: "format null args",
+			prog: compile(`format("%s|%d|%.1f|%t|%v", x, x, x, x, x)`),
+			exp:  "|0|0.0|false|",
+		},
+		{
+			name: "format literal percent",
+			prog: compile(`format("%d%% of %s", 50, "%!s")`),
+			exp:  "50% of %!s",
+		},
+		{
+			name: "format args count error",
+			prog: compile("format()"),
+			err:  true,
+		},
+		{
+			name: "format missing arg error",
+			prog: compile(`format("%s %s", "a")`),
+			err:  true,
+		},
+		{
+			name: "format extra arg error",
+			prog: compile(`format("%s", "a", "b")`),
+			err:  true,
+		},
+		{
+			name: "format verb error",
+			prog: compile(`format("%d", "a")`),
+			err:  true,
+		},
+		{
+			name: "format missing verb error",
+			prog: compile(`format("a %")`),
+			err:  true,
+		},
+		{
+			name: "format arg index error",
+			prog: compile(`format("%[1]s", "a")`),
+			err:  true,
+		},
+	}
+
+	testVM(t, tests)
+}
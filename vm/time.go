@@ -0,0 +1,231 @@
+package vm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stevecallear/mexl/types"
+)
+
+var timeBuiltIns = map[string]types.Func{
+	"now": func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen("now", args, 0); err != nil {
+			return nil, err
+		}
+
+		return &types.Time{Value: time.Now()}, nil
+	},
+
+	"date": func(args ...types.Object) (types.Object, error) {
+		return convertString("date", args, types.TypeTime)
+	},
+
+	"duration": func(args ...types.Object) (types.Object, error) {
+		return convertString("duration", args, types.TypeDuration)
+	},
+
+	"year": timePart("year", func(t time.Time) int {
+		return t.Year()
+	}),
+
+	"month": timePart("month", func(t time.Time) int {
+		return int(t.Month())
+	}),
+
+	"day": timePart("day", func(t time.Time) int {
+		return t.Day()
+	}),
+
+	"hour": timePart("hour", func(t time.Time) int {
+		return t.Hour()
+	}),
+
+	"minute": timePart("minute", func(t time.Time) int {
+		return t.Minute()
+	}),
+
+	"weekday": timePart("weekday", func(t time.Time) int {
+		return int(t.Weekday())
+	}),
+}
+
+func init() {
+	for k, v := range timeBuiltIns {
+		builtIns[k] = v
+	}
+}
+
+// convertString converts the string argument to the specified type
+func convertString(name string, args []types.Object, t types.Type) (types.Object, error) {
+	if err := expectArgsLen(name, args, 1); err != nil {
+		return nil, err
+	}
+
+	switch args[0].Type() {
+	case types.TypeNull:
+		return objNull, nil
+
+	case types.TypeString, t:
+		o, ok := types.Convert(args[0], t)
+		if !ok {
+			return nil, fmt.Errorf("%s: invalid %s: %s", name, strings.ToLower(string(t)), args[0].Inspect())
+		}
+		return o, nil
+
+	default:
+		return nil, fmt.Errorf("%s: wrong arg type: %s, expected %s", name, args[0].Type(), types.TypeString)
+	}
+}
+
+// timePart returns a function that returns the integer part of a time argument
+func timePart(name string, fn func(time.Time) int) types.Func {
+	return func(args ...types.Object) (types.Object, error) {
+		if err := expectArgsLen(name, args, 1); err != nil {
+			return nil, err
+		}
+
+		switch a := args[0].(type) {
+		case *types.Null:
+			return objNull, nil
+
+		case *types.Time:
+			return types.NewInteger(int64(fn(a.Value))), nil
+
+		default:
+			return nil, fmt.Errorf("%s: wrong arg type: %s, expected %s", name, args[0].Type(), types.TypeTime)
+		}
+	}
+}
+
+func (vm *VM) execBinaryTimeOp(op Opcode, left, right types.Object) error {
+	switch l := left.(type) {
+	case *types.Time:
+		switch r := right.(type) {
+		case *types.Duration:
+			switch op {
+			case OpAdd:
+				vm.push(&types.Time{Value: l.Value.Add(r.Value)})
+				return nil
+			case OpSubtract:
+				vm.push(&types.Time{Value: l.Value.Add(-r.Value)})
+				return nil
+			}
+
+		case *types.Time:
+			if op == OpSubtract {
+				vm.push(&types.Duration{Value: l.Value.Sub(r.Value)})
+				return nil
+			}
+		}
+
+	case *types.Duration:
+		switch r := right.(type) {
+		case *types.Time:
+			if op == OpAdd {
+				vm.push(&types.Time{Value: r.Value.Add(l.Value)})
+				return nil
+			}
+
+		case *types.Duration:
+			switch op {
+			case OpAdd:
+				vm.push(&types.Duration{Value: l.Value + r.Value})
+				return nil
+			case OpSubtract:
+				vm.push(&types.Duration{Value: l.Value - r.Value})
+				return nil
+			case OpDivide:
+				if r.Value == 0 {
+					return ErrDivisionByZero
+				}
+				vm.push(&types.Float{Value: float64(l.Value) / float64(r.Value)})
+				return nil
+			case OpModulus:
+				if r.Value == 0 {
+					return ErrDivisionByZero
+				}
+				vm.push(&types.Duration{Value: l.Value % r.Value})
+				return nil
+			}
+
+		case *types.Integer:
+			switch op {
+			case OpMultiply:
+				vm.push(&types.Duration{Value: l.Value * time.Duration(r.Value)})
+				return nil
+			case OpDivide:
+				if r.Value == 0 {
+					return ErrDivisionByZero
+				}
+				vm.push(&types.Duration{Value: l.Value / time.Duration(r.Value)})
+				return nil
+			}
+
+		case *types.Float:
+			switch op {
+			case OpMultiply:
+				vm.push(&types.Duration{Value: time.Duration(float64(l.Value) * r.Value)})
+				return nil
+			case OpDivide:
+				if r.Value == 0 {
+					return ErrDivisionByZero
+				}
+				vm.push(&types.Duration{Value: time.Duration(float64(l.Value) / r.Value)})
+				return nil
+			}
+		}
+
+	case *types.Integer, *types.Float:
+		if r, ok := right.(*types.Duration); ok && op == OpMultiply {
+			return vm.execBinaryTimeOp(op, r, left)
+		}
+	}
+
+	return fmt.Errorf("unsupported type for binary operation: %s, %s", left.Type(), right.Type())
+}
+
+func (vm *VM) execTimeComparison(op Opcode, left, right types.Object) error {
+	var c int
+	switch l := left.(type) {
+	case *types.Time:
+		c = l.Value.Compare(right.(*types.Time).Value)
+	case *types.Duration:
+		c = compare(l.Value, right.(*types.Duration).Value)
+	}
+
+	switch op {
+	case OpLess:
+		vm.push(boolToObject(c < 0))
+
+	case OpLessOrEqual:
+		vm.push(boolToObject(c <= 0))
+
+	case OpGreater:
+		vm.push(boolToObject(c > 0))
+
+	case OpGreaterOrEqual:
+		vm.push(boolToObject(c >= 0))
+
+	default:
+		return fmt.Errorf("unknown time comparison operator: %d", op)
+	}
+
+	return nil
+}
+
+func compare(a, b time.Duration) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func isTemporal(o types.Object) bool {
+	t := o.Type()
+	return t == types.TypeTime || t == types.TypeDuration
+}
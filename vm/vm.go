@@ -1,20 +1,49 @@
 package vm
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math/big"
+	"slices"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/stevecallear/mexl/ast/token"
 	"github.com/stevecallear/mexl/types"
 )
 
-const stackSize = 2048
+const (
+	// DefaultStackSize is the default maximum stack size
+	DefaultStackSize = 2048
 
-type VM struct {
-	program     *Program
-	environment types.Map
-	stack       []types.Object
-	sp          int
-}
+	// cancelCheckInterval is the number of instructions between context cancellation checks
+	cancelCheckInterval = 256
+)
+
+type (
+	VM struct {
+		program     *Program
+		environment types.Resolver
+		stack       []types.Object
+		sp          int
+		locals      []types.Object
+		strictNull  bool
+		maxSteps    int
+		ctx         context.Context
+		err         error
+	}
+
+	// Option represents a VM option
+	Option func(*VM)
+
+	// Error represents a runtime error and the source span that caused it
+	Error struct {
+		Err   error
+		Start token.Position
+		End   token.Position
+	}
+)
 
 var (
 	objTrue  = &types.Boolean{Value: true}
@@ -22,16 +51,103 @@ var (
 	objNull  = &types.Null{}
 )
 
-func New(p *Program, env types.Map) *VM {
-	return &VM{
+var (
+	// ErrStackOverflow is returned if the program exceeds the maximum stack size
+	ErrStackOverflow = errors.New("stack overflow")
+
+	// ErrNullOperand is returned if an operand is null in strict null mode
+	ErrNullOperand = errors.New("null operand")
+
+	// ErrStepLimit is returned if the program exceeds the maximum number of instructions
+	ErrStepLimit = errors.New("step limit exceeded")
+
+	// ErrDivisionByZero is returned if an integer, decimal or duration is divided by zero
+	ErrDivisionByZero = errors.New("division by zero")
+
+	// ErrFunctionPanic is returned if a function panics
+	ErrFunctionPanic = errors.New("function panic")
+
+	// ErrInvalidStackSize is returned by Run if the VM is created with a negative stack size
+	ErrInvalidStackSize = errors.New("invalid stack size")
+)
+
+func New(p *Program, env types.Resolver, opts ...Option) *VM {
+	if env == nil {
+		env = types.Map(nil)
+	}
+
+	vm := &VM{
 		program:     p,
 		environment: env,
-		stack:       make([]types.Object, stackSize),
 		sp:          0,
 	}
+
+	for _, fn := range opts {
+		fn(vm)
+	}
+
+	if vm.stack == nil {
+		vm.stack = make([]types.Object, DefaultStackSize)
+	}
+
+	if p.Locals > 0 {
+		vm.locals = make([]types.Object, p.Locals)
+	}
+
+	return vm
+}
+
+// WithStrictNull disables null coalescing, causing operations on null values to return an error
+func WithStrictNull() Option {
+	return func(vm *VM) {
+		vm.strictNull = true
+	}
+}
+
+// WithStackSize sets the maximum stack size
+// A negative size causes Run to return ErrInvalidStackSize.
+func WithStackSize(n int) Option {
+	return func(vm *VM) {
+		if n < 0 {
+			vm.err = fmt.Errorf("%w: %d", ErrInvalidStackSize, n)
+			return
+		}
+		vm.stack = make([]types.Object, n)
+	}
+}
+
+// WithMaxSteps limits the number of instructions executed by a single run
+func WithMaxSteps(n int) Option {
+	return func(vm *VM) {
+		vm.maxSteps = n
+	}
+}
+
+// Reset resets the VM to run the program with the specified environment
+// This allows the VM and its stack to be reused, but a VM must not be used concurrently.
+func (vm *VM) Reset(env types.Resolver) {
+	if env == nil {
+		env = types.Map(nil)
+	}
+
+	vm.environment = env
+	vm.sp = 0
 }
 
 func (vm *VM) Run() (types.Object, error) {
+	return vm.RunContext(context.Background())
+}
+
+// RunContext runs the program, returning an error if the context is cancelled
+// Cancellation is checked periodically and before each function call.
+func (vm *VM) RunContext(ctx context.Context) (types.Object, error) {
+	if vm.err != nil {
+		return nil, vm.err
+	}
+
+	vm.ctx = ctx
+	defer func() { vm.ctx = nil }()
+
 	if err := vm.run(); err != nil {
 		return nil, err
 	}
@@ -39,9 +155,37 @@ func (vm *VM) Run() (types.Object, error) {
 }
 
 func (vm *VM) run() (err error) {
+	var ip, steps int
+	done := vm.ctx.Done()
+
+	defer func() {
+		if r := recover(); r != nil {
+			if r != ErrStackOverflow {
+				panic(r)
+			}
+			err = ErrStackOverflow
+		}
+
+		if err != nil {
+			err = vm.error(ip, err)
+		}
+	}()
+
 	for i := 0; i < len(vm.program.Instructions); i++ {
+		ip = i
 		op := Opcode(vm.program.Instructions[i])
 
+		if done != nil && steps%cancelCheckInterval == 0 {
+			if err = vm.ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		steps++
+		if vm.maxSteps > 0 && steps > vm.maxSteps {
+			return ErrStepLimit
+		}
+
 		switch op {
 		case OpConstant:
 			cidx := readUint16(vm.program.Instructions[i+1:])
@@ -53,6 +197,13 @@ func (vm *VM) run() (err error) {
 			i += 2
 			vm.execArray(alen)
 
+		case OpMap:
+			mlen := readUint16(vm.program.Instructions[i+1:])
+			i += 2
+			if err = vm.execMap(mlen); err != nil {
+				return err
+			}
+
 		case OpTrue:
 			vm.push(objTrue)
 
@@ -72,7 +223,7 @@ func (vm *VM) run() (err error) {
 				return err
 			}
 
-		case OpLess, OpLessOrEqual, OpGreater, OpGreaterOrEqual, OpAnd, OpOr, OpStartsWith, OpEndsWith:
+		case OpLess, OpLessOrEqual, OpGreater, OpGreaterOrEqual, OpAnd, OpOr, OpStartsWith, OpEndsWith, OpLike, OpILike:
 			if err = vm.execComparison(op); err != nil {
 				return err
 			}
@@ -82,6 +233,19 @@ func (vm *VM) run() (err error) {
 				return err
 			}
 
+		case OpMatch:
+			if err = vm.execMatch(); err != nil {
+				return err
+			}
+
+		case OpMatchPattern, OpFindPattern:
+			idx := readUint16(vm.program.Instructions[i+1:])
+			i += 2
+
+			if err = vm.execPattern(op, vm.program.Patterns[idx], vm.program.Calls[i-2]); err != nil {
+				return err
+			}
+
 		case OpNot:
 			vm.execBangOp()
 
@@ -95,11 +259,24 @@ func (vm *VM) run() (err error) {
 				return err
 			}
 
+		case OpSlice:
+			bounds := readUint8(vm.program.Instructions[i+1:])
+			i++
+
+			if err = vm.execSliceExpression(bounds); err != nil {
+				return err
+			}
+
 		case OpGlobal:
 			idx := readUint8(vm.program.Instructions[i+1:])
 			i++
 			vm.execIdentifier(vm.program.Identifiers[idx])
 
+		case OpFunction:
+			idx := readUint8(vm.program.Instructions[i+1:])
+			i++
+			vm.push(vm.program.Functions[idx])
+
 		case OpMember:
 			idx := readUint8(vm.program.Instructions[i+1:])
 			i++
@@ -112,14 +289,71 @@ func (vm *VM) run() (err error) {
 			nargs := readUint8(vm.program.Instructions[i+1:])
 			i++
 
-			if err = vm.execCallExpression(nargs); err != nil {
+			if err = vm.execCallExpression(nargs, vm.program.Calls[i-1]); err != nil {
+				return err
+			}
+
+		case OpBuiltIn:
+			idx := readUint8(vm.program.Instructions[i+1:])
+			nargs := readUint8(vm.program.Instructions[i+2:])
+			i += 2
+
+			if err = vm.execBuiltIn(vm.program.Identifiers[idx], nargs); err != nil {
 				return err
 			}
 
 		case OpJumpIfFalse, OpJumpIfTrue:
 			pos := readUint16(vm.program.Instructions[i+1:])
 			i += 2
-			i = vm.execJump(op, i, int(pos))
+
+			if i, err = vm.execJump(op, i, int(pos)); err != nil {
+				return err
+			}
+
+		case OpJumpIfNotNull:
+			pos := readUint16(vm.program.Instructions[i+1:])
+			i += 2
+
+			if vm.peek().Type() != types.TypeNull {
+				i = int(pos) - 1
+			}
+
+		case OpLocal:
+			slot := readUint8(vm.program.Instructions[i+1:])
+			i++
+			vm.push(vm.locals[slot])
+
+		case OpIter:
+			kind := readUint8(vm.program.Instructions[i+1:])
+			i++
+
+			if err = vm.execIter(IterKind(kind)); err != nil {
+				return err
+			}
+
+		case OpIterNext:
+			slot := readUint8(vm.program.Instructions[i+1:])
+			pos := readUint16(vm.program.Instructions[i+2:])
+			i += 3
+
+			if !vm.execIterNext(slot) {
+				i = int(pos) - 1
+			}
+
+		case OpIterStep:
+			if err = vm.execIterStep(); err != nil {
+				return err
+			}
+
+		case OpIterEnd:
+			vm.execIterEnd()
+
+		case OpJump:
+			pos := readUint16(vm.program.Instructions[i+1:])
+			i = int(pos) - 1
+
+		case OpPop:
+			vm.pop()
 
 		default:
 			return fmt.Errorf("invalid opcode: %d", op)
@@ -133,6 +367,10 @@ func (vm *VM) execBinaryOp(op Opcode) error {
 	r := vm.pop()
 	l := vm.pop()
 
+	if err := vm.checkNull(l, r); err != nil {
+		return err
+	}
+
 	l, r = types.Coerce(l, r)
 	lt, rt := l.Type(), r.Type()
 
@@ -143,9 +381,15 @@ func (vm *VM) execBinaryOp(op Opcode) error {
 	case lt == types.TypeFloat && rt == types.TypeFloat:
 		return vm.execBinaryFloatOp(op, l, r)
 
+	case lt == types.TypeDecimal && rt == types.TypeDecimal:
+		return vm.execBinaryDecimalOp(op, l, r)
+
 	case lt == types.TypeString && rt == types.TypeString:
 		return vm.execBinaryStringOp(op, l, r)
 
+	case isTemporal(l) || isTemporal(r):
+		return vm.execBinaryTimeOp(op, l, r)
+
 	default:
 		return fmt.Errorf("unsupported type for binary operation: %s, %s", lt, rt)
 	}
@@ -157,25 +401,33 @@ func (vm *VM) execBinaryIntegerOp(op Opcode, left, right types.Object) error {
 
 	switch op {
 	case OpAdd:
-		vm.push(&types.Integer{Value: l + r})
+		vm.push(types.NewInteger(l + r))
 
 	case OpSubtract:
-		vm.push(&types.Integer{Value: l - r})
+		vm.push(types.NewInteger(l - r))
 
 	case OpMultiply:
-		vm.push(&types.Integer{Value: l * r})
+		vm.push(types.NewInteger(l * r))
 
 	case OpDivide:
+		if r == 0 {
+			return ErrDivisionByZero
+		}
+
 		switch l % r {
 		case 0:
-			vm.push(&types.Integer{Value: l / r})
+			vm.push(types.NewInteger(l / r))
 
 		default:
 			vm.push(&types.Float{Value: float64(l) / float64(r)})
 		}
 
 	case OpModulus:
-		vm.push(&types.Integer{Value: l % r})
+		if r == 0 {
+			return ErrDivisionByZero
+		}
+
+		vm.push(types.NewInteger(l % r))
 
 	default:
 		return fmt.Errorf("unknown integer operator: %d", op)
@@ -218,7 +470,7 @@ func (vm *VM) execBinaryStringOp(op Opcode, left, right types.Object) error {
 		return fmt.Errorf("unknown string operator: %d", op)
 	}
 
-	vm.push(&types.String{Value: l + r})
+	vm.push(types.NewString(l + r))
 	return nil
 }
 
@@ -246,6 +498,10 @@ func (vm *VM) execComparison(op Opcode) error {
 	r := vm.pop()
 	l := vm.pop()
 
+	if err := vm.checkNull(l, r); err != nil {
+		return err
+	}
+
 	l, r = types.Coerce(l, r)
 	lt, rt := l.Type(), r.Type()
 
@@ -256,12 +512,18 @@ func (vm *VM) execComparison(op Opcode) error {
 	case lt == types.TypeFloat && rt == types.TypeFloat:
 		return vm.execFloatComparison(op, l, r)
 
+	case lt == types.TypeDecimal && rt == types.TypeDecimal:
+		return vm.execDecimalComparison(op, l, r)
+
 	case lt == types.TypeBoolean && rt == types.TypeBoolean:
 		return vm.execBoolComparison(op, l, r)
 
 	case lt == types.TypeString && rt == types.TypeString:
 		return vm.execStringComparison(op, l, r)
 
+	case lt == rt && isTemporal(l):
+		return vm.execTimeComparison(op, l, r)
+
 	default:
 		return fmt.Errorf("unknown comparison operator: %d (%s %s)", op, l.Type(), r.Type())
 	}
@@ -344,6 +606,9 @@ func (vm *VM) execStringComparison(op Opcode, left, right types.Object) error {
 	case OpEndsWith:
 		vm.push(boolToObject(strings.HasSuffix(l, r)))
 
+	case OpLike, OpILike:
+		vm.push(boolToObject(like(l, r, op == OpILike)))
+
 	default:
 		return fmt.Errorf("unknown string comparison operator: %d", op)
 	}
@@ -368,18 +633,30 @@ func (vm *VM) execBangOp() {
 func (vm *VM) execMinusOp() error {
 	o := vm.pop()
 
+	if err := vm.checkNull(o); err != nil {
+		return err
+	}
+
 	switch o.Type() {
 	case types.TypeNull:
 		o = objNull
 
 	case types.TypeInteger:
 		v := o.(*types.Integer).Value
-		o = &types.Integer{Value: -v}
+		o = types.NewInteger(-v)
 
 	case types.TypeFloat:
 		v := o.(*types.Float).Value
 		o = &types.Float{Value: -v}
 
+	case types.TypeDecimal:
+		v := o.(*types.Decimal).Value
+		o = &types.Decimal{Value: new(big.Rat).Neg(v)}
+
+	case types.TypeDuration:
+		v := o.(*types.Duration).Value
+		o = &types.Duration{Value: -v}
+
 	default:
 		return fmt.Errorf("unsupported type for negation: %s", o.Type())
 	}
@@ -391,6 +668,11 @@ func (vm *VM) execMinusOp() error {
 func (vm *VM) execInOp() error {
 	r := vm.pop()
 	l := vm.pop()
+
+	if err := vm.checkNull(l, r); err != nil {
+		return err
+	}
+
 	lt, rt := l.Type(), r.Type()
 
 	switch {
@@ -428,7 +710,7 @@ func (vm *VM) execArrayInOp(left, right types.Object) {
 }
 
 func (vm *VM) execIdentifier(n string) {
-	if obj, ok := vm.environment[n]; ok {
+	if obj, ok := vm.environment.Get(n); ok {
 		vm.push(obj)
 		return
 	}
@@ -438,7 +720,7 @@ func (vm *VM) execIdentifier(n string) {
 		return
 	}
 
-	vm.push(objNull)
+	vm.push(types.Missing)
 }
 
 func (vm *VM) execArray(alen uint16) {
@@ -451,22 +733,84 @@ func (vm *VM) execArray(alen uint16) {
 	vm.push(a)
 }
 
+func (vm *VM) execMap(mlen uint16) error {
+	m := make(types.Map, mlen)
+
+	base := vm.sp - int(mlen)*2
+	for i := base; i < vm.sp; i += 2 {
+		k, ok := vm.stack[i].(*types.String)
+		if !ok {
+			return fmt.Errorf("invalid map key type: %s", vm.stack[i].Type())
+		}
+		m[k.Value] = vm.stack[i+1]
+	}
+
+	vm.sp = base
+	vm.push(m)
+	return nil
+}
+
 func (vm *VM) execIndexExpression() error {
 	index := vm.pop()
 	left := vm.pop()
 
-	switch {
-	case left.Type() == types.TypeNull && index.Type() == types.TypeInteger:
-		vm.push(objNull)
+	if err := vm.checkNull(left, index); err != nil {
+		return err
+	}
+
+	switch l := left.(type) {
+	case *types.Null:
+		if !isIndexType(index) {
+			return fmt.Errorf("invalid index type: %s", index.Type())
+		}
+		vm.push(types.Missing)
+
+	case types.Array:
+		if index.Type() == types.TypeNull {
+			vm.push(types.Missing)
+			return nil
+		}
 
-	case left.Type() == types.TypeArray && index.Type() == types.TypeInteger:
-		a := left.(types.Array)
-		i := index.(*types.Integer).Value
+		i, ok := index.(*types.Integer)
+		if !ok {
+			return fmt.Errorf("invalid index type: %s[%s]", left.Type(), index.Type())
+		}
 
-		if i >= 0 && int(i) < len(a) {
-			vm.push(a[i])
+		if n, ok := indexOf(i.Value, len(l)); ok {
+			vm.push(l[n])
 		} else {
-			vm.push(objNull)
+			vm.push(types.Missing)
+		}
+
+	case *types.String:
+		if index.Type() == types.TypeNull {
+			vm.push(types.Missing)
+			return nil
+		}
+
+		i, ok := index.(*types.Integer)
+		if !ok {
+			return fmt.Errorf("invalid index type: %s[%s]", left.Type(), index.Type())
+		}
+
+		if r, ok := runeAt(l.Value, i.Value); ok {
+			vm.push(types.NewString(string(r)))
+		} else {
+			vm.push(types.Missing)
+		}
+
+	case types.Resolver:
+		switch k := index.(type) {
+		case *types.String:
+			if v, ok := l.Get(k.Value); ok {
+				vm.push(v)
+			} else {
+				vm.push(types.Missing)
+			}
+		case *types.Null:
+			vm.push(types.Missing)
+		default:
+			return fmt.Errorf("invalid index type: %s[%s]", left.Type(), index.Type())
 		}
 
 	default:
@@ -476,64 +820,271 @@ func (vm *VM) execIndexExpression() error {
 	return nil
 }
 
-func (vm *VM) execMemberExpression(idx int) error {
+func isIndexType(o types.Object) bool {
+	switch o.Type() {
+	case types.TypeInteger, types.TypeString, types.TypeNull:
+		return true
+	default:
+		return false
+	}
+}
+
+// indexOf returns the index within a sequence of length n, with negative indices relative to n
+func indexOf(i int64, n int) (int, bool) {
+	if i < 0 {
+		i += int64(n)
+	}
+
+	if i < 0 || i >= int64(n) {
+		return 0, false
+	}
+	return int(i), true
+}
+
+// runeAt returns the rune at index i of the string
+func runeAt(s string, i int64) (rune, bool) {
+	if i < 0 {
+		i += int64(utf8.RuneCountInString(s))
+		if i < 0 {
+			return 0, false
+		}
+	}
+
+	var n int64
+	for _, r := range s {
+		if n == i {
+			return r, true
+		}
+		n++
+	}
+
+	return 0, false
+}
+
+// execSliceExpression slices an array or string, clamping the bounds to the length
+// The bounds operand indicates which of the low and high bounds are on the stack.
+func (vm *VM) execSliceExpression(bounds uint8) error {
+	var low, high types.Object
+	if bounds&SliceHigh != 0 {
+		high = vm.pop()
+	}
+	if bounds&SliceLow != 0 {
+		low = vm.pop()
+	}
 	left := vm.pop()
 
-	switch left.Type() {
-	case types.TypeMap:
-		if m, ok := left.(types.Map)[vm.program.Identifiers[idx]]; ok {
-			vm.push(m)
-		} else {
-			vm.push(objNull)
+	for _, o := range []types.Object{left, low, high} {
+		if o == nil {
+			continue
+		}
+		if err := vm.checkNull(o); err != nil {
+			return err
 		}
+	}
 
-	case types.TypeNull:
+	var n int
+	switch l := left.(type) {
+	case types.Array:
+		n = len(l)
+	case *types.String:
+		n = utf8.RuneCountInString(l.Value)
+	case *types.Null:
+	default:
+		return fmt.Errorf("slice operator not supported: %s", left.Type())
+	}
+
+	i, err := sliceBound(low, 0, n)
+	if err != nil {
+		return err
+	}
+
+	j, err := sliceBound(high, n, n)
+	if err != nil {
+		return err
+	}
+
+	j = max(i, j)
+
+	switch l := left.(type) {
+	case types.Array:
+		vm.push(l[i:j:j])
+	case *types.String:
+		vm.push(types.NewString(string([]rune(l.Value)[i:j])))
+	default:
 		vm.push(objNull)
+	}
+
+	return nil
+}
+
+// sliceBound returns the bound clamped to [0, n], with negative bounds relative to n
+func sliceBound(o types.Object, def, n int) (int, error) {
+	switch b := o.(type) {
+	case nil, *types.Null:
+		return def, nil
+
+	case *types.Integer:
+		i := b.Value
+		if i < 0 {
+			i += int64(n)
+		}
+		return int(min(max(i, 0), int64(n))), nil
 
 	default:
+		return 0, fmt.Errorf("invalid slice index type: %s", o.Type())
+	}
+}
+
+func (vm *VM) execMemberExpression(idx int) error {
+	left := vm.pop()
+
+	if err := vm.checkNull(left); err != nil {
+		return err
+	}
+
+	if left.Type() == types.TypeNull {
+		vm.push(types.Missing)
+		return nil
+	}
+
+	r, ok := left.(types.Resolver)
+	if !ok {
 		return fmt.Errorf("container not supported: %s", left.Type())
 	}
 
+	if m, ok := r.Get(vm.program.Identifiers[idx]); ok {
+		vm.push(m)
+	} else {
+		vm.push(types.Missing)
+	}
+
 	return nil
 }
 
-func (vm *VM) execCallExpression(nargs uint8) error {
-	args := make([]types.Object, int(nargs))
+func (vm *VM) execCallExpression(nargs uint8, name string) error {
+	// args are copied as the function may retain them
+	args := slices.Clone(vm.stack[vm.sp-int(nargs) : vm.sp])
+	vm.sp -= int(nargs)
+
+	fn := vm.pop()
+
+	return vm.execCall(name, fn, args)
+}
 
-	for i := len(args) - 1; i >= 0; i-- {
-		args[i] = vm.pop()
+// execBuiltIn calls the named built in function, unless the name is shadowed by the environment
+// Built in functions do not retain args, so are passed a view of the stack to avoid allocation.
+func (vm *VM) execBuiltIn(name string, nargs uint8) error {
+	args := vm.stack[vm.sp-int(nargs) : vm.sp : vm.sp]
+	vm.sp -= int(nargs)
+
+	fn, ok := vm.environment.Get(name)
+	if ok {
+		args = slices.Clone(args)
+	} else {
+		fn = builtIns[name]
 	}
 
-	fn := vm.pop()
+	return vm.execCall(name, fn, args)
+}
 
-	switch fn.Type() {
-	case types.TypeFunc:
-		obj, err := fn.(types.Func)(args...)
-		if err != nil {
-			return err
+func (vm *VM) execCall(name string, fn types.Object, args []types.Object) error {
+	if err := vm.ctx.Err(); err != nil {
+		return err
+	}
+
+	obj, err := vm.call(name, fn, args)
+	if err != nil {
+		return err
+	}
+
+	vm.push(obj)
+	return nil
+}
+
+// call calls the function, recovering any panic as an error
+func (vm *VM) call(name string, fn types.Object, args []types.Object) (obj types.Object, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s: %w: %v", name, ErrFunctionPanic, r)
 		}
+	}()
 
-		vm.push(obj)
-		return nil
+	switch f := fn.(type) {
+	case types.Func:
+		return f(args...)
+
+	case types.ContextFunc:
+		return f(vm.ctx, args...)
 
 	default:
-		return fmt.Errorf("invalid function type: %T", fn)
+		return nil, fmt.Errorf("invalid function type: %T", fn)
 	}
 }
 
-func (vm *VM) execJump(op Opcode, cpos, jpos int) int {
-	cond := vm.peek().(*types.Boolean)
+func (vm *VM) execJump(op Opcode, cpos, jpos int) (int, error) {
+	cond, err := vm.truthy(vm.peek())
+	if err != nil {
+		return cpos, err
+	}
 
-	if (op == OpJumpIfFalse && !cond.Value) || (op == OpJumpIfTrue && cond.Value) {
+	if (op == OpJumpIfFalse && !cond) || (op == OpJumpIfTrue && cond) {
+		// the condition is left as the result of a short circuited operation
+		vm.stack[vm.sp-1] = boolToObject(cond)
 		cpos = jpos - 1
 	}
 
-	return cpos
+	return cpos, nil
+}
+
+// truthy returns the boolean value of a condition, treating null as false
+func (vm *VM) truthy(o types.Object) (bool, error) {
+	if err := vm.checkNull(o); err != nil {
+		return false, err
+	}
+
+	switch t := o.(type) {
+	case *types.Boolean:
+		return t.Value, nil
+
+	case *types.Null:
+		return false, nil
+
+	default:
+		return false, fmt.Errorf("invalid condition type: %s", o.Type())
+	}
+}
+
+// checkNull returns an error if strict null mode is enabled and any of the operands are null
+func (vm *VM) checkNull(objs ...types.Object) error {
+	if !vm.strictNull {
+		return nil
+	}
+
+	for _, o := range objs {
+		if o.Type() == types.TypeNull {
+			return ErrNullOperand
+		}
+	}
+
+	return nil
+}
+
+func (vm *VM) error(ip int, err error) error {
+	s, ok := vm.program.Spans[ip]
+	if !ok {
+		return &Error{Err: err}
+	}
+
+	return &Error{
+		Err:   err,
+		Start: s.Start,
+		End:   s.End,
+	}
 }
 
 func (vm *VM) push(o types.Object) {
-	if vm.sp >= stackSize {
-		panic("stack overflow")
+	if vm.sp >= len(vm.stack) {
+		panic(ErrStackOverflow)
 	}
 
 	vm.stack[vm.sp] = o
@@ -557,3 +1108,16 @@ func boolToObject(b bool) *types.Boolean {
 	}
 	return objFalse
 }
+
+// Error returns the error message prefixed with the start position
+func (e *Error) Error() string {
+	if !e.Start.IsValid() {
+		return e.Err.Error()
+	}
+	return e.Start.String() + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -1,8 +1,14 @@
 package vm_test
 
 import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stevecallear/mexl/compiler"
 	"github.com/stevecallear/mexl/parser"
@@ -14,6 +20,7 @@ type testCase struct {
 	name string
 	prog *vm.Program
 	env  types.Map
+	opts []vm.Option
 	exp  any
 	err  bool
 }
@@ -137,6 +144,22 @@ func TestBooleanExpressions(t *testing.T) {
 		newTestCase(`"abc" sw "c"`, false),
 		newTestCase(`"abc" ew "a"`, false),
 		newTestCase(`"abc" ew "c"`, true),
+		newTestCase(`"abc" matches "^a.c$"`, true),
+		newTestCase(`"abc" =~ "^b"`, false),
+		newTestCase(`"/api/v1/users" like "/api/*/users"`, true),
+		newTestCase(`"/api/users" like "/api/*/users"`, false),
+		newTestCase(`"a@corp.com" like "%@corp.com"`, true),
+		newTestCase(`"a@corp.com.au" like "%@corp.com"`, false),
+		newTestCase(`"abc" like "a_c"`, true),
+		newTestCase(`"héllo" like "h?llo"`, true),
+		newTestCase(`"abc" like "a?"`, false),
+		newTestCase(`"mississippi" like "m*issip*"`, true),
+		newTestCase(`"50%" like "50\%"`, true),
+		newTestCase(`"500" like "50\%"`, false),
+		newTestCase(`"" like "%"`, true),
+		newTestCase(`"ABC" like "abc"`, false),
+		newTestCase(`"ABC" ilike "a%c"`, true),
+		newTestCase(`x like "%"`, true),
 		newTestCase(`1 in ["a", 2, 3.3]`, false),
 		newTestCase(`2 in ["a", 2, 3.3]`, true),
 		newTestCase(`"a" in "bc"`, false),
@@ -147,6 +170,8 @@ func TestBooleanExpressions(t *testing.T) {
 		newTestCase(`1 ne null`, true),
 		newTestCase(`false and x eq 1`, false),
 		newTestCase(`true or x eq 1`, true),
+		newTestCase(`x and true`, false),
+		newTestCase(`x or true`, true),
 	}
 
 	testVM(t, tests)
@@ -186,89 +211,991 @@ func TestArrays(t *testing.T) {
 			prog: compile("[1][1]"),
 			exp:  nil,
 		},
+		{
+			name: "index operator negative",
+			prog: compile("[1, 2, 3][-1]"),
+			exp:  3,
+		},
 		{
 			name: "index operator out of range (negative)",
-			prog: compile("[1][-1]"),
+			prog: compile("[1][-2]"),
+			exp:  nil,
+		},
+		{
+			name: "index operator on null",
+			prog: compile("null[0]"),
+			exp:  nil,
+		},
+		{
+			name: "index operator null index",
+			prog: compile("[1, 2, 3][x]"),
+			env:  types.Map{"x": &types.Null{}},
+			exp:  nil,
+		},
+		{
+			name: "index operator missing index",
+			prog: compile("[1, 2, 3][x]"),
+			exp:  nil,
+		},
+		{
+			name: "index operator null index strict",
+			prog: compile("[1, 2, 3][x]"),
+			opts: []vm.Option{vm.WithStrictNull()},
+			err:  true,
+		},
+		{
+			name: "string index null index",
+			prog: compile(`"abc"[x]`),
+			exp:  nil,
+		},
+		{
+			name: "index operator invalid type",
+			prog: compile(`[1]["a"]`),
+			err:  true,
+		},
+		{
+			name: "string index",
+			prog: compile(`"héllo"[1]`),
+			exp:  "é",
+		},
+		{
+			name: "string index negative",
+			prog: compile(`"héllo"[-1]`),
+			exp:  "o",
+		},
+		{
+			name: "string index out of range",
+			prog: compile(`"a"[1]`),
+			exp:  nil,
+		},
+		{
+			name: "slice",
+			prog: compile("[1, 2, 3, 4][1:3]"),
+			exp:  []any{2, 3},
+		},
+		{
+			name: "slice low",
+			prog: compile("[1, 2, 3][1:]"),
+			exp:  []any{2, 3},
+		},
+		{
+			name: "slice high",
+			prog: compile("[1, 2, 3][:2]"),
+			exp:  []any{1, 2},
+		},
+		{
+			name: "slice negative",
+			prog: compile("[1, 2, 3][-2:]"),
+			exp:  []any{2, 3},
+		},
+		{
+			name: "slice clamped",
+			prog: compile("[1, 2, 3][-10:10]"),
+			exp:  []any{1, 2, 3},
+		},
+		{
+			name: "slice empty",
+			prog: compile("[1, 2, 3][2:1]"),
+			exp:  []any{},
+		},
+		{
+			name: "slice null bound",
+			prog: compile("[1, 2, 3][x:]"),
+			exp:  []any{1, 2, 3},
+		},
+		{
+			name: "slice null bound strict",
+			prog: compile("[1, 2, 3][x:]"),
+			opts: []vm.Option{vm.WithStrictNull()},
+			err:  true,
+		},
+		{
+			name: "slice string",
+			prog: compile(`"héllo"[1:3]`),
+			exp:  "él",
+		},
+		{
+			name: "slice null",
+			prog: compile("null[1:]"),
+			exp:  nil,
+		},
+		{
+			name: "slice invalid type",
+			prog: compile("1[1:]"),
+			err:  true,
+		},
+		{
+			name: "slice invalid bound type",
+			prog: compile(`[1]["a":]`),
+			err:  true,
+		},
+	}
+
+	testVM(t, tests)
+}
+
+func TestMaps(t *testing.T) {
+	tests := []testCase{
+		{
+			name: "root",
+			prog: compile("x"),
+			env: types.Map{
+				"x": &types.Integer{Value: 1},
+			},
+			exp: 1,
+		},
+		{
+			name: "member",
+			prog: compile("x.y.z"),
+			env: types.Map{
+				"x": types.Map{
+					"y": types.Map{
+						"z": &types.Integer{Value: 1},
+					},
+				},
+			},
+			exp: 1,
+		},
+		{
+			name: "literal",
+			prog: compile(`{a: 1, "b c": [x], d: {}}`),
+			env: types.Map{
+				"x": &types.String{Value: "x"},
+			},
+			exp: map[string]any{"a": 1, "b c": []any{"x"}, "d": map[string]any{}},
+		},
+		{
+			name: "literal member",
+			prog: compile(`{a: {b: 1}}.a.b`),
+			exp:  1,
+		},
+		{
+			name: "literal equality",
+			prog: compile(`x eq {a: 1}`),
+			env: types.Map{
+				"x": types.Map{"a": &types.Integer{Value: 1}},
+			},
+			exp: true,
+		},
+		{
+			name: "invalid root",
+			prog: compile("invalid.x"),
+			exp:  nil,
+		},
+		{
+			name: "invalid member",
+			prog: compile("x.invalid"),
+			env: types.Map{
+				"x": types.Map{
+					"y": &types.Integer{Value: 1},
+				},
+			},
+			exp: nil,
+		},
+		{
+			name: "index",
+			prog: compile(`headers["X-Request-Id"]`),
+			env: types.Map{
+				"headers": types.Map{"X-Request-Id": &types.String{Value: "a"}},
+			},
+			exp: "a",
+		},
+		{
+			name: "computed index",
+			prog: compile(`attrs[key + "b"]`),
+			env: types.Map{
+				"attrs": types.Map{"ab": &types.Integer{Value: 1}},
+				"key":   &types.String{Value: "a"},
+			},
+			exp: 1,
+		},
+		{
+			name: "missing index",
+			prog: compile(`defined(x["y"]) or defined(x["y"]["z"])`),
+			env: types.Map{
+				"x": types.Map{},
+			},
+			exp: false,
+		},
+		{
+			name: "null index",
+			prog: compile(`x[y]`),
+			env: types.Map{
+				"x": types.Map{"y": &types.Integer{Value: 1}},
+			},
+			exp: nil,
+		},
+		{
+			name: "null index strict",
+			prog: compile(`x[y]`),
+			env: types.Map{
+				"x": types.Map{"y": &types.Integer{Value: 1}},
+			},
+			opts: []vm.Option{vm.WithStrictNull()},
+			err:  true,
+		},
+		{
+			name: "invalid index type",
+			prog: compile(`x[1]`),
+			env: types.Map{
+				"x": types.Map{},
+			},
+			err: true,
+		},
+	}
+
+	testVM(t, tests)
+}
+
+func TestGlobals(t *testing.T) {
+	tests := []testCase{
+		{
+			name: "constant",
+			prog: compile("x"),
+			env: types.Map{
+				"x": &types.Boolean{Value: true},
+			},
+			exp: true,
+		},
+		{
+			name: "boolean expression",
+			prog: compile("x < 4"),
+			env: types.Map{
+				"x": &types.Integer{Value: 5},
+			},
+			exp: false,
+		},
+		{
+			name: "arithmetic operation",
+			prog: compile("x + y"),
+			env: types.Map{
+				"x": &types.Integer{Value: 5},
+				"y": &types.Integer{Value: 3},
+			},
+			exp: 8,
+		},
+	}
+
+	testVM(t, tests)
+}
+
+type testResolver struct {
+	resolved []string
+}
+
+func (r *testResolver) Type() types.Type          { return "RESOLVER" }
+func (r *testResolver) Equal(o types.Object) bool { return r == o }
+func (r *testResolver) Inspect() string           { return "resolver" }
+
+func (r *testResolver) Get(name string) (types.Object, bool) {
+	r.resolved = append(r.resolved, name)
+	switch name {
+	case "self":
+		return r, true
+	case "name":
+		return &types.String{Value: name}, true
+	default:
+		return nil, false
+	}
+}
+
+func TestResolver(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		exp      any
+		resolved []string
+	}{
+		{
+			name:     "global",
+			input:    `name`,
+			exp:      "name",
+			resolved: []string{"name"},
+		},
+		{
+			name:     "member",
+			input:    `self.name`,
+			exp:      "name",
+			resolved: []string{"self", "name"},
+		},
+		{
+			name:     "missing member",
+			input:    `self.other`,
+			exp:      nil,
+			resolved: []string{"self", "other"},
+		},
+		{
+			name:     "short circuit",
+			input:    `true or self.name eq "a"`,
+			exp:      true,
+			resolved: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := new(testResolver)
+
+			out, err := vm.New(compile(tt.input), r).Run()
+			if err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+
+			assertObject(t, out, tt.exp)
+			if !reflect.DeepEqual(r.resolved, tt.resolved) {
+				t.Errorf("got %v, expected %v", r.resolved, tt.resolved)
+			}
+		})
+	}
+}
+
+func TestConditionals(t *testing.T) {
+	env := types.Map{
+		"t": &types.Boolean{Value: true},
+		"f": &types.Boolean{Value: false},
+	}
+
+	tests := []testCase{
+		{
+			name: "ternary true",
+			prog: compile("t ? 1 : 2"),
+			env:  env,
+			exp:  1,
+		},
+		{
+			name: "ternary false",
+			prog: compile("f ? 1 : 2"),
+			env:  env,
+			exp:  2,
+		},
+		{
+			name: "if",
+			prog: compile(`if 1 lt 2 then "a" else "b"`),
+			exp:  "a",
+		},
+		{
+			name: "nested",
+			prog: compile("f ? 1 : t ? (f ? 2 : 3) : 4"),
+			env:  env,
+			exp:  3,
+		},
+		{
+			name: "expression",
+			prog: compile("1 + (t ? 2 : 3) * 2"),
+			env:  env,
+			exp:  5,
+		},
+		{
+			name: "null condition",
+			prog: compile("x.y ? 1 : 2"),
+			exp:  2,
+		},
+		{
+			name: "null condition strict",
+			prog: compile("x ? 1 : 2"),
+			opts: []vm.Option{vm.WithStrictNull()},
+			err:  true,
+		},
+		{
+			name: "invalid condition",
+			prog: compile("1 ? 1 : 2"),
+			err:  true,
+		},
+		{
+			name: "unevaluated branch",
+			prog: compile(`t ? 1 : "a" - 1`),
+			env:  env,
+			exp:  1,
+		},
+	}
+
+	testVM(t, tests)
+}
+
+func TestIterations(t *testing.T) {
+	env := types.Map{
+		"orders": types.Array{
+			types.Map{"total": &types.Integer{Value: 50}},
+			types.Map{"total": &types.Integer{Value: 150}},
+		},
+		"fn": types.Func(func(args ...types.Object) (types.Object, error) {
+			return nil, errors.New("evaluated")
+		}),
+	}
+
+	tests := []testCase{
+		{
+			name: "any",
+			prog: compile("any(orders, o => o.total gt 100)"),
+			env:  env,
+			exp:  true,
+		},
+		{
+			name: "any empty",
+			prog: compile("any([], o => true)"),
+			exp:  false,
+		},
+		{
+			name: "all",
+			prog: compile("all(orders, o => o.total gt 100)"),
+			env:  env,
+			exp:  false,
+		},
+		{
+			name: "all empty",
+			prog: compile("all([], o => false)"),
+			exp:  true,
+		},
+		{
+			name: "none",
+			prog: compile("none(orders, o => o.total gt 1000)"),
+			env:  env,
+			exp:  true,
+		},
+		{
+			name: "filter",
+			prog: compile("filter([1, 2, 3, 4], x => x % 2 eq 0)"),
+			exp:  []any{2, 4},
+		},
+		{
+			name: "map",
+			prog: compile("map(orders, o => o.total * 2)"),
+			env:  env,
+			exp:  []any{100, 300},
+		},
+		{
+			name: "nested",
+			prog: compile("map([[1, 2], [3]], a => map(a, b => b + len(a)))"),
+			exp:  []any{[]any{3, 4}, []any{4}},
+		},
+		{
+			name: "outer parameter",
+			prog: compile("any([1, 2], a => any([2, 3], b => a eq b))"),
+			exp:  true,
+		},
+		{
+			name: "short circuit",
+			prog: compile("any([1, 2], x => x eq 1 or fn())"),
+			env:  env,
+			exp:  true,
+		},
+		{
+			name: "null collection",
+			prog: compile("filter(x, o => true)"),
+			exp:  []any{},
+		},
+		{
+			name: "null collection strict",
+			prog: compile("any(x, o => true)"),
+			opts: []vm.Option{vm.WithStrictNull()},
+			err:  true,
+		},
+		{
+			name: "invalid collection",
+			prog: compile(`any("a", o => true)`),
+			err:  true,
+		},
+		{
+			name: "invalid predicate",
+			prog: compile("any([1], o => o)"),
+			err:  true,
+		},
+	}
+
+	testVM(t, tests)
+}
+
+func TestPatterns(t *testing.T) {
+	env := types.Map{
+		"email":   &types.String{Value: "test@corp.com"},
+		"pattern": &types.String{Value: "@corp[.]com$"},
+		"invalid": &types.String{Value: "("},
+	}
+
+	tests := []testCase{
+		{
+			name: "literal",
+			prog: compile(`email matches "@corp[.]com$"`),
+			env:  env,
+			exp:  true,
+		},
+		{
+			name: "dynamic",
+			prog: compile(`email =~ pattern`),
+			env:  env,
+			exp:  true,
+		},
+		{
+			name: "null",
+			prog: compile(`x matches "^$"`),
+			exp:  true,
+		},
+		{
+			name: "null strict",
+			prog: compile(`x matches "^$"`),
+			opts: []vm.Option{vm.WithStrictNull()},
+			err:  true,
+		},
+		{
+			name: "invalid type",
+			prog: compile(`1 matches "a"`),
+			err:  true,
+		},
+		{
+			name: "invalid dynamic pattern",
+			prog: compile(`email matches invalid`),
+			env:  env,
+			err:  true,
+		},
+		{
+			name: "regexMatch",
+			prog: compile(`regexMatch(email, pattern)`),
+			env:  env,
+			exp:  true,
+		},
+		{
+			name: "regexFind",
+			prog: compile(`regexFind(email, "[a-z]+[.]com")`),
+			env:  env,
+			exp:  "corp.com",
+		},
+		{
+			name: "regexFind dynamic",
+			prog: compile(`regexFind(email, "^" + "[a-z]+")`),
+			env:  env,
+			exp:  "test",
+		},
+		{
+			name: "regexFind no match",
+			prog: compile(`regexFind(email, "[0-9]+")`),
+			env:  env,
+			exp:  nil,
+		},
+		{
+			name: "regexFind invalid pattern",
+			prog: compile(`regexFind(email, invalid)`),
+			env:  env,
+			err:  true,
+		},
+	}
+
+	testVM(t, tests)
+}
+
+func TestPatternErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		exp   string
+	}{
+		{`x matches "a+"`, "matches: wrong arg type: INTEGER"},
+		{`x =~ y`, "matches: wrong arg type: INTEGER"},
+		{`regexMatch(x, "a+")`, "regexMatch: wrong arg type: INTEGER"},
+		{`regexFind(x, "a+")`, "regexFind: wrong arg type: INTEGER"},
+	}
+
+	env := types.Map{
+		"x": &types.Integer{Value: 1},
+		"y": types.NewString("a+"),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := vm.New(compile(tt.input), env).Run()
+			if err == nil || !strings.Contains(err.Error(), tt.exp) {
+				t.Errorf("got %v, expected %s", err, tt.exp)
+			}
+		})
+	}
+}
+
+func TestTime(t *testing.T) {
+	expiry := time.Date(2024, 1, 2, 10, 30, 0, 0, time.UTC)
+
+	env := types.Map{
+		"expiry": &types.Time{Value: expiry},
+		"ttl":    &types.Duration{Value: 90 * time.Minute},
+	}
+
+	tests := []testCase{
+		{
+			name: "add duration",
+			prog: compile("expiry + ttl"),
+			env:  env,
+			exp:  &types.Time{Value: expiry.Add(90 * time.Minute)},
+		},
+		{
+			name: "add time",
+			prog: compile("ttl + expiry"),
+			env:  env,
+			exp:  &types.Time{Value: expiry.Add(90 * time.Minute)},
+		},
+		{
+			name: "subtract duration",
+			prog: compile("expiry - ttl"),
+			env:  env,
+			exp:  &types.Time{Value: expiry.Add(-90 * time.Minute)},
+		},
+		{
+			name: "subtract time",
+			prog: compile(`expiry - date("2024-01-01")`),
+			env:  env,
+			exp:  &types.Duration{Value: 34*time.Hour + 30*time.Minute},
+		},
+		{
+			name: "duration arithmetic",
+			prog: compile(`(ttl + duration("30m")) * 2 - ttl / 3`),
+			env:  env,
+			exp:  &types.Duration{Value: 3*time.Hour + 30*time.Minute},
+		},
+		{
+			name: "duration scale",
+			prog: compile("1.5 * ttl"),
+			env:  env,
+			exp:  &types.Duration{Value: 135 * time.Minute},
+		},
+		{
+			name: "duration ratio",
+			prog: compile(`ttl / duration("1h")`),
+			env:  env,
+			exp:  1.5,
+		},
+		{
+			name: "duration modulus",
+			prog: compile(`ttl % duration("1h")`),
+			env:  env,
+			exp:  &types.Duration{Value: 30 * time.Minute},
+		},
+		{
+			name: "negate duration",
+			prog: compile("-ttl"),
+			env:  env,
+			exp:  &types.Duration{Value: -90 * time.Minute},
+		},
+		{
+			name: "compare time",
+			prog: compile(`expiry gt date("2024-01-02") and expiry lt now()`),
+			env:  env,
+			exp:  true,
+		},
+		{
+			name: "compare time string",
+			prog: compile(`expiry ge "2024-01-02T10:30:00Z" and "2024-01-03" gt expiry`),
+			env:  env,
+			exp:  true,
+		},
+		{
+			name: "compare duration",
+			prog: compile(`ttl gt duration("1h") and ttl le "90m"`),
+			env:  env,
+			exp:  true,
+		},
+		{
+			name: "equal time",
+			prog: compile(`expiry eq date("2024-01-02T10:30:00Z")`),
+			env:  env,
+			exp:  true,
+		},
+		{
+			name: "time parts",
+			prog: compile("[year(expiry), month(expiry), day(expiry), hour(expiry), minute(expiry), weekday(expiry)]"),
+			env:  env,
+			exp:  []any{2024, 1, 2, 10, 30, 2},
+		},
+		{
+			name: "null time part",
+			prog: compile("hour(x)"),
+			exp:  nil,
+		},
+		{
+			name: "null date",
+			prog: compile("date(x)"),
 			exp:  nil,
 		},
 		{
-			name: "index operator on null",
-			prog: compile("null[0]"),
-			exp:  nil,
+			name: "invalid date",
+			prog: compile(`date("a")`),
+			err:  true,
+		},
+		{
+			name: "invalid duration",
+			prog: compile(`duration("a")`),
+			err:  true,
+		},
+		{
+			name: "invalid time part",
+			prog: compile(`hour("a")`),
+			err:  true,
+		},
+		{
+			name: "invalid arithmetic",
+			prog: compile("expiry + 1"),
+			env:  env,
+			err:  true,
+		},
+		{
+			name: "invalid comparison",
+			prog: compile("expiry lt ttl"),
+			env:  env,
+			err:  true,
 		},
 	}
 
 	testVM(t, tests)
 }
 
-func TestMaps(t *testing.T) {
+func TestDecimal(t *testing.T) {
+	env := types.Map{
+		"price": &types.Decimal{Value: big.NewRat(1999, 100)},
+	}
+
 	tests := []testCase{
 		{
-			name: "root",
-			prog: compile("x"),
-			env: types.Map{
-				"x": &types.Integer{Value: 1},
-			},
-			exp: 1,
+			name: "addition",
+			prog: compile("0.1d + 0.2d eq 0.3d"),
+			exp:  true,
 		},
 		{
-			name: "member",
-			prog: compile("x.y.z"),
-			env: types.Map{
-				"x": types.Map{
-					"y": types.Map{
-						"z": &types.Integer{Value: 1},
-					},
-				},
-			},
-			exp: 1,
+			name: "integer coercion",
+			prog: compile("price * 3"),
+			env:  env,
+			exp:  &types.Decimal{Value: big.NewRat(5997, 100)},
 		},
 		{
-			name: "invalid root",
-			prog: compile("invalid.x"),
+			name: "float coercion",
+			prog: compile("0.1 + price"),
+			env:  env,
+			exp:  &types.Decimal{Value: big.NewRat(2009, 100)},
+		},
+		{
+			name: "division",
+			prog: compile("1d / 3"),
+			exp:  &types.Decimal{Value: big.NewRat(1, 3)},
+		},
+		{
+			name: "negate",
+			prog: compile("-price"),
+			env:  env,
+			exp:  &types.Decimal{Value: big.NewRat(-1999, 100)},
+		},
+		{
+			name: "compare",
+			prog: compile("price gt 19.98 and price lt 20"),
+			env:  env,
+			exp:  true,
+		},
+		{
+			name: "constructor",
+			prog: compile(`decimal("1.10") eq 1.1d`),
+			exp:  true,
+		},
+		{
+			name: "null constructor",
+			prog: compile("decimal(x)"),
 			exp:  nil,
 		},
 		{
-			name: "invalid member",
-			prog: compile("x.invalid"),
-			env: types.Map{
-				"x": types.Map{
-					"y": &types.Integer{Value: 1},
-				},
+			name: "invalid constructor",
+			prog: compile(`decimal("a")`),
+			err:  true,
+		},
+		{
+			name: "division by zero",
+			prog: compile("price / 0"),
+			env:  env,
+			err:  true,
+		},
+		{
+			name: "modulus",
+			prog: compile("[price % 2, -price % 2, 7.5d % 2.5d, 5 % 1.5d]"),
+			env:  env,
+			exp: []any{
+				&types.Decimal{Value: big.NewRat(199, 100)},
+				&types.Decimal{Value: big.NewRat(-199, 100)},
+				&types.Decimal{Value: big.NewRat(0, 1)},
+				&types.Decimal{Value: big.NewRat(1, 2)},
 			},
-			exp: nil,
+		},
+		{
+			name: "modulus by zero",
+			prog: compile("price % 0"),
+			env:  env,
+			err:  true,
 		},
 	}
 
 	testVM(t, tests)
 }
 
-func TestGlobals(t *testing.T) {
+func TestRounding(t *testing.T) {
 	tests := []testCase{
 		{
-			name: "constant",
-			prog: compile("x"),
-			env: types.Map{
-				"x": &types.Boolean{Value: true},
+			name: "round decimal",
+			prog: compile("round(1d / 3, 2)"),
+			exp:  &types.Decimal{Value: big.NewRat(33, 100)},
+		},
+		{
+			name: "round decimal half",
+			prog: compile("[round(2.5d), round(-2.5d), round(1.005d, 2)]"),
+			exp: []any{
+				&types.Decimal{Value: big.NewRat(3, 1)},
+				&types.Decimal{Value: big.NewRat(-3, 1)},
+				&types.Decimal{Value: big.NewRat(101, 100)},
 			},
-			exp: true,
 		},
 		{
-			name: "boolean expression",
-			prog: compile("x < 4"),
-			env: types.Map{
-				"x": &types.Integer{Value: 5},
+			name: "round float",
+			prog: compile("[round(2.5), round(1.25, 1)]"),
+			exp:  []any{3.0, 1.3},
+		},
+		{
+			name: "round integer",
+			prog: compile("round(2, 1)"),
+			exp:  2,
+		},
+		{
+			name: "floor",
+			prog: compile("[floor(1.5), floor(-1.5d), floor(2)]"),
+			exp: []any{
+				1.0,
+				&types.Decimal{Value: big.NewRat(-2, 1)},
+				2,
 			},
-			exp: false,
 		},
 		{
-			name: "arithmetic operation",
-			prog: compile("x + y"),
-			env: types.Map{
-				"x": &types.Integer{Value: 5},
-				"y": &types.Integer{Value: 3},
+			name: "ceil",
+			prog: compile("[ceil(1.5), ceil(-1.5d), ceil(2)]"),
+			exp: []any{
+				2.0,
+				&types.Decimal{Value: big.NewRat(-1, 1)},
+				2,
 			},
-			exp: 8,
+		},
+		{
+			name: "null",
+			prog: compile("[round(x), floor(x), ceil(x)]"),
+			exp:  []any{nil, nil, nil},
+		},
+		{
+			name: "invalid places",
+			prog: compile("round(1.5, -1)"),
+			err:  true,
+		},
+		{
+			name: "places beyond limit",
+			prog: compile("round(1.5d, 1025)"),
+			err:  true,
+		},
+		{
+			name: "places beyond float precision",
+			prog: compile("[round(1.5, 400), round(0.1, 20), round(x, 2)]"),
+			env:  types.Map{"x": &types.Float{Value: 1e300}},
+			exp:  []any{1.5, 0.1, 1e300},
+		},
+		{
+			name: "invalid type",
+			prog: compile(`floor("a")`),
+			err:  true,
+		},
+	}
+
+	testVM(t, tests)
+}
+
+func TestCoalesce(t *testing.T) {
+	env := types.Map{
+		"x": types.Map{
+			"empty": &types.Null{},
+			"value": &types.String{Value: "a"},
+		},
+		"a": types.Array{&types.Null{}},
+		"fn": types.Func(func(args ...types.Object) (types.Object, error) {
+			return nil, errors.New("evaluated")
+		}),
+	}
+
+	tests := []testCase{
+		{
+			name: "missing",
+			prog: compile(`x.missing ?? "b"`),
+			env:  env,
+			exp:  "b",
+		},
+		{
+			name: "explicit null",
+			prog: compile(`x.empty ?? "b"`),
+			env:  env,
+			exp:  "b",
+		},
+		{
+			name: "value",
+			prog: compile(`x.value ?? fn()`),
+			env:  env,
+			exp:  "a",
+		},
+		{
+			name: "chained",
+			prog: compile(`x.missing ?? x.empty ?? 1`),
+			env:  env,
+			exp:  1,
+		},
+		{
+			name: "strict null",
+			prog: compile(`y ?? 1`),
+			opts: []vm.Option{vm.WithStrictNull()},
+			exp:  1,
+		},
+		{
+			name: "coalesce builtin",
+			prog: compile(`coalesce(x.missing, x.empty, x.value, 1)`),
+			env:  env,
+			exp:  "a",
+		},
+		{
+			name: "coalesce builtin null",
+			prog: compile(`coalesce(x.missing)`),
+			env:  env,
+			exp:  nil,
+		},
+		{
+			name: "defined missing",
+			prog: compile(`defined(x.missing)`),
+			env:  env,
+			exp:  false,
+		},
+		{
+			name: "defined missing root",
+			prog: compile(`defined(y.missing)`),
+			env:  env,
+			exp:  false,
+		},
+		{
+			name: "defined explicit null",
+			prog: compile(`defined(x.empty)`),
+			env:  env,
+			exp:  true,
+		},
+		{
+			name: "defined value",
+			prog: compile(`defined(x.value)`),
+			env:  env,
+			exp:  true,
+		},
+		{
+			name: "defined out of range index",
+			prog: compile(`[defined(a[1]), defined(a[-2]), defined(x.value[5]), defined(a[y])]`),
+			env:  env,
+			exp:  []any{false, false, false, false},
+		},
+		{
+			name: "defined explicit null element",
+			prog: compile(`[defined(a[0]), defined(a[-1]), defined(x.value[0])]`),
+			env:  env,
+			exp:  []any{true, true, true},
+		},
+		{
+			name: "defined args count error",
+			prog: compile(`defined()`),
+			err:  true,
 		},
 	}
 
@@ -292,6 +1219,261 @@ func TestNull(t *testing.T) {
 	testVM(t, tests)
 }
 
+func TestStrictNull(t *testing.T) {
+	strict := []vm.Option{vm.WithStrictNull()}
+
+	tests := []testCase{
+		{
+			name: "equality",
+			prog: compile("x eq null"),
+			opts: strict,
+			exp:  true,
+		},
+		{
+			name: "binary op",
+			prog: compile("x + 1"),
+			opts: strict,
+			err:  true,
+		},
+		{
+			name: "comparison",
+			prog: compile("x lt 1"),
+			opts: strict,
+			err:  true,
+		},
+		{
+			name: "negation",
+			prog: compile("-x"),
+			opts: strict,
+			err:  true,
+		},
+		{
+			name: "in",
+			prog: compile("x in [1]"),
+			opts: strict,
+			err:  true,
+		},
+		{
+			name: "index",
+			prog: compile("x[0]"),
+			opts: strict,
+			err:  true,
+		},
+		{
+			name: "member",
+			prog: compile("x.y"),
+			opts: strict,
+			err:  true,
+		},
+		{
+			name: "non null",
+			prog: compile("x + 1"),
+			env:  types.Map{"x": &types.Integer{Value: 1}},
+			opts: strict,
+			exp:  2,
+		},
+	}
+
+	testVM(t, tests)
+}
+
+func TestStackSize(t *testing.T) {
+	_, err := vm.New(compile("[1, 2, 3]"), nil, vm.WithStackSize(2)).Run()
+	if !errors.Is(err, vm.ErrStackOverflow) {
+		t.Errorf("got %v, expected %v", err, vm.ErrStackOverflow)
+	}
+
+	_, err = vm.New(compile("1"), nil, vm.WithStackSize(-1)).Run()
+	if !errors.Is(err, vm.ErrInvalidStackSize) {
+		t.Errorf("got %v, expected %v", err, vm.ErrInvalidStackSize)
+	}
+}
+
+func TestDivisionByZero(t *testing.T) {
+	env := types.Map{
+		"zero": &types.Integer{Value: 0},
+		"ttl":  &types.Duration{Value: time.Minute},
+	}
+
+	tests := []string{
+		"1 / 0",
+		"1 % zero",
+		"1.5d / 0",
+		"1.5d % 0",
+		"ttl / 0",
+		"ttl / 0.0",
+		`ttl / duration("0s")`,
+		`ttl % duration("0s")`,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			_, err := vm.New(compile(tt), env).Run()
+			if !errors.Is(err, vm.ErrDivisionByZero) {
+				t.Errorf("got %v, expected %v", err, vm.ErrDivisionByZero)
+			}
+		})
+	}
+}
+
+func TestFunctionPanic(t *testing.T) {
+	env := types.Map{
+		"explode": types.Func(func(args ...types.Object) (types.Object, error) {
+			return args[1], nil
+		}),
+	}
+
+	_, err := vm.New(compile("explode(1)"), env).Run()
+	if !errors.Is(err, vm.ErrFunctionPanic) {
+		t.Fatalf("got %v, expected %v", err, vm.ErrFunctionPanic)
+	}
+
+	if !strings.Contains(err.Error(), "explode: function panic") {
+		t.Errorf("got %v, expected the function name", err)
+	}
+}
+
+func TestRetainedArgs(t *testing.T) {
+	env := types.Map{
+		"list": types.Func(func(args ...types.Object) (types.Object, error) {
+			return types.Array(args), nil
+		}),
+		"pair": types.ContextFunc(func(_ context.Context, args ...types.Object) (types.Object, error) {
+			return types.Array(args), nil
+		}),
+	}
+
+	testVM(t, []testCase{
+		{
+			name: "array literal",
+			prog: compile("[list(1, 2), 3, 4]"),
+			env:  env,
+			exp:  []any{[]any{1, 2}, 3, 4},
+		},
+		{
+			name: "map literal",
+			prog: compile(`{a: list(1, 2), b: 7}`),
+			env:  env,
+			exp:  map[string]any{"a": []any{1, 2}, "b": 7},
+		},
+		{
+			name: "shadowed built in",
+			prog: compile("[len(1, 2), 3, 4]"),
+			env:  types.Map{"len": env["list"]},
+			exp:  []any{[]any{1, 2}, 3, 4},
+		},
+		{
+			name: "nested call",
+			prog: compile("[pair(list(1, 2), 3), 4, 5]"),
+			env:  env,
+			exp:  []any{[]any{[]any{1, 2}, 3}, 4, 5},
+		},
+	})
+}
+
+func TestReset(t *testing.T) {
+	sut := vm.New(compile("x + 1"), types.Map{"x": &types.Integer{Value: 1}})
+
+	for i, exp := range []int{2, 3, 4} {
+		if i > 0 {
+			sut.Reset(types.Map{"x": &types.Integer{Value: int64(exp - 1)}})
+		}
+
+		out, err := sut.Run()
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		assertObject(t, out, exp)
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var ctxKey struct{}
+
+	tests := []struct {
+		name string
+		prog *vm.Program
+		env  types.Map
+		ctx  context.Context
+		opts []vm.Option
+		exp  any
+		err  error
+	}{
+		{
+			name: "background",
+			prog: compile("1 + 2"),
+			ctx:  context.Background(),
+			exp:  3,
+		},
+		{
+			name: "cancelled",
+			prog: compile("1 + 2"),
+			ctx:  cancelled,
+			err:  context.Canceled,
+		},
+		{
+			name: "context func",
+			prog: compile("fn()"),
+			env: types.Map{
+				"fn": types.ContextFunc(func(ctx context.Context, args ...types.Object) (types.Object, error) {
+					return ctx.Value(ctxKey).(types.Object), nil
+				}),
+			},
+			ctx: context.WithValue(context.Background(), ctxKey, &types.String{Value: "a"}),
+			exp: "a",
+		},
+		{
+			name: "within step limit",
+			prog: compile("1 + 2"),
+			ctx:  context.Background(),
+			opts: []vm.Option{vm.WithMaxSteps(3)},
+			exp:  3,
+		},
+		{
+			name: "step limit exceeded",
+			prog: compile("1 + 2"),
+			ctx:  context.Background(),
+			opts: []vm.Option{vm.WithMaxSteps(2)},
+			err:  vm.ErrStepLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := vm.New(tt.prog, tt.env, tt.opts...).RunContext(tt.ctx)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, expected %v", err, tt.err)
+			}
+			if err == nil {
+				assertObject(t, out, tt.exp)
+			}
+		})
+	}
+}
+
+func TestRunContext_FuncCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	env := types.Map{
+		"cancel": types.Func(func(args ...types.Object) (types.Object, error) {
+			cancel()
+			return &types.Null{}, nil
+		}),
+		"fn": types.Func(func(args ...types.Object) (types.Object, error) {
+			t.Error("got call, expected cancellation")
+			return &types.Null{}, nil
+		}),
+	}
+
+	_, err := vm.New(compile("cancel() eq fn()"), env).RunContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, expected %v", err, context.Canceled)
+	}
+}
+
 func TestErrors(t *testing.T) {
 	tests := []testCase{
 		{
@@ -346,7 +1528,7 @@ func TestErrors(t *testing.T) {
 		},
 		{
 			name: "invalid index op type",
-			prog: compile(`"a"[0]`),
+			prog: compile(`1[0]`),
 			err:  true,
 		},
 		{
@@ -364,6 +1546,19 @@ func TestErrors(t *testing.T) {
 	testVM(t, tests)
 }
 
+func TestError(t *testing.T) {
+	_, err := vm.New(compile(`1 + ("a" - 1)`), nil).Run()
+
+	var act *vm.Error
+	if !errors.As(err, &act) {
+		t.Fatalf("got %T, expected vm.Error", err)
+	}
+
+	if act.Start.Offset != 5 || act.End.Offset != 12 {
+		t.Errorf("got %d-%d, expected 5-12", act.Start.Offset, act.End.Offset)
+	}
+}
+
 func testVM(t *testing.T, tests []testCase) {
 	t.Helper()
 
@@ -374,7 +1569,7 @@ func testVM(t *testing.T, tests []testCase) {
 		}
 
 		t.Run(n, func(t *testing.T) {
-			out, err := vm.New(tt.prog, tt.env).Run()
+			out, err := vm.New(tt.prog, tt.env, tt.opts...).Run()
 			if err != nil && !tt.err {
 				t.Fatalf("got %v, expected nil", err)
 			}
@@ -402,6 +1597,12 @@ func assertObject(t *testing.T, act types.Object, exp any) {
 		assertStringObject(t, act, exp)
 	case []any:
 		assertArrayObject(t, act, exp)
+	case map[string]any:
+		assertMapObject(t, act, exp)
+	case types.Object:
+		if !act.Equal(exp) {
+			t.Errorf("got %s, expected %s", act.Inspect(), exp.Inspect())
+		}
 	default:
 		t.Errorf("got %s, expected %T", act.Type(), exp)
 	}
@@ -462,6 +1663,22 @@ func assertStringObject(t *testing.T, act any, exp string) {
 	}
 }
 
+func assertMapObject(t *testing.T, act any, exp map[string]any) {
+	obj, ok := act.(types.Map)
+	if !ok {
+		t.Errorf("got %T, expected types.Map", act)
+		return
+	}
+
+	if len(obj) != len(exp) {
+		t.Errorf("got %d entries, expected %d", len(obj), len(exp))
+	}
+
+	for k, exp := range exp {
+		assertObject(t, obj[k], exp)
+	}
+}
+
 func assertArrayObject(t *testing.T, act any, exp []any) {
 	obj, ok := act.(types.Array)
 	if !ok {